@@ -0,0 +1,93 @@
+package protodb
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"time"
+
+	"github.com/jmoiron/sqlx/reflectx"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Clock returns the current time used by the "autocreate", "autoupdate" and "softdelete" columns
+type Clock func() time.Time
+
+// WithClock overrides the clock (time.Now by default) used to fill the "autocreate", "autoupdate"
+// and "softdelete" columns (e.g. to make tests deterministic).
+// Example:
+//      ctx = protodb.WithClock(ctx, func() time.Time {
+//          return time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+//      })
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockKey, clock)
+}
+
+// now returns the current time of the clock of ctx
+func now(ctx context.Context) time.Time {
+	if clock, ok := ctx.Value(clockKey).(Clock); ok && clock != nil {
+		return clock()
+	}
+	return time.Now()
+}
+
+// isAutoTime returns true if the column is flagged with one of the flags ("autocreate", "autoupdate")
+func isAutoTime(v TagData, flags ...string) bool {
+	for _, flag := range flags {
+		if v.MetaFlag(flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeValue returns t as the value to be written in the column of field: time.Time (for time.Time,
+// *time.Time, sql.NullTime and *timestamppb.Timestamp fields) or an integer with the unix time
+func timeValue(field reflect.Value, t time.Time) interface{} {
+	switch reflectx.Deref(field.Type()).Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return t.Unix()
+	}
+	return t
+}
+
+// timeColumnValue returns the value of an "autocreate" or "autoupdate" column
+// (*timestamppb.Timestamp is converted to time.Time)
+func timeColumnValue(v TagData) interface{} {
+	if ts, ok := v.FieldValue.Interface().(*timestamppb.Timestamp); ok && ts != nil {
+		return ts.AsTime()
+	}
+	return resolveValue(v)
+}
+
+// setTimeValue sets field (if settable) to t. Supported types: time.Time, *time.Time, sql.NullTime,
+// *timestamppb.Timestamp and integers (unix time). The zero time.Time clears the field.
+func setTimeValue(field reflect.Value, t time.Time) {
+	if !field.IsValid() || !field.CanSet() {
+		return
+	}
+	if t.IsZero() {
+		field.Set(reflect.Zero(field.Type()))
+		return
+	}
+	var v interface{}
+	switch field.Interface().(type) {
+	case time.Time:
+		v = t
+	case *time.Time:
+		v = &t
+	case sql.NullTime:
+		v = sql.NullTime{Time: t, Valid: true}
+	case *timestamppb.Timestamp:
+		v = timestamppb.New(t)
+	default:
+		switch field.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			field.SetInt(t.Unix())
+		case reflect.Uint, reflect.Uint32, reflect.Uint64:
+			field.SetUint(uint64(t.Unix()))
+		}
+		return
+	}
+	field.Set(reflect.ValueOf(v))
+}
@@ -0,0 +1,74 @@
+package protodb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type timestampedAgent struct {
+	ID        int                    `db:"id,table=agents"`
+	Name      string                 `db:"name"`
+	CreatedAt time.Time              `db:"created_at,autocreate"`
+	UpdatedAt *timestamppb.Timestamp `db:"updated_at,autoupdate"`
+}
+
+func TestAutoTimestamps(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	t0 := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	clock := t0
+	ctx := protodb.WithClock(context.Background(), func() time.Time { return clock })
+
+	item := &timestampedAgent{ID: 1, Name: "a"}
+	mock.ExpectExec("INSERT INTO agents \\(id,name,created_at,updated_at\\)").
+		WithArgs(1, "a", t0, t0).WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err := protodb.InsertContext(ctx, db, item, nil)
+	require.NoError(t, err)
+	require.Equal(t, t0, item.CreatedAt)
+	require.Equal(t, t0, item.UpdatedAt.AsTime())
+
+	// autocreate is not updated
+	clock = t1
+	mock.ExpectExec("UPDATE agents SET id = \\?, name = \\?, updated_at = \\? WHERE id = \\?").
+		WithArgs(1, "a", t1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = protodb.UpdateContext(ctx, db, item, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", item.ID)
+	})
+	require.NoError(t, err)
+	require.Equal(t, t0, item.CreatedAt)
+	require.Equal(t, t1, item.UpdatedAt.AsTime())
+
+	// values already set are kept on insert
+	items := []timestampedAgent{{ID: 2, Name: "b", CreatedAt: t0}, {ID: 3, Name: "c"}}
+	mock.ExpectExec("INSERT INTO agents \\(id,name,created_at,updated_at\\)").
+		WithArgs(2, "b", t0, t1, 3, "c", t1, t1).WillReturnResult(sqlmock.NewResult(1, 2))
+	_, err = protodb.InsertContext(ctx, db, &items, nil)
+	require.NoError(t, err)
+	require.Equal(t, t1, items[1].CreatedAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAutoTimestampsUpsert(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	t0 := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	ctx := protodb.WithClock(context.Background(), func() time.Time { return t0 })
+
+	item := &timestampedAgent{ID: 1, Name: "a"}
+	mock.ExpectExec("ON DUPLICATE KEY UPDATE id = VALUES\\(id\\), name = VALUES\\(name\\), updated_at = VALUES\\(updated_at\\)$").
+		WithArgs(1, "a", t0, t0).WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err := protodb.UpsertContext(ctx, db, item, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
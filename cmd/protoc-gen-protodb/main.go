@@ -0,0 +1,264 @@
+// The protoc-gen-protodb binary is a protoc plugin that generates the protodb.Model methods of the
+// messages annotated with the protodb options (protodbpb/options.proto), so the column scans (and the
+// CRUD functions) do not extract the columns with protoreflect.
+//
+// Usage:
+//      go install github.com/pedidopago/protodb/cmd/protoc-gen-protodb
+//      protoc --go_out=. --go_opt=paths=source_relative --protodb_out=. --protodb_opt=paths=source_relative order.proto
+//
+// A <name>.protodb.go file is generated next to the <name>.pb.go file of each .proto file with at
+// least one message with the protodb.table option. For each message (e.g. Order) it contains:
+//      const OrderTable = "orders o"         // the table option
+//      var OrderColumns = []string{...}      // the column names
+//      var OrderJoins = []string{...}        // the joins of the columns
+//      func (x *Order) ProtodbColumns(selecting bool) []protodb.TagData
+//      func (x *Order) ProtodbScan(columns []string) ([]interface{}, error)
+//      func (x *Order) ProtodbValue(column string) (interface{}, bool)
+// The columns are mapped by protodb.ProtoColumns (like protodb.ProtoColumnScan maps them). The
+// FieldValue of the generated columns is still a reflect.Value of the field, used by the features
+// that write the fields (e.g. "autocreate" and "version").
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pedidopago/protodb"
+	"github.com/pedidopago/protodb/protodbpb"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	protodbPackage = protogen.GoImportPath("github.com/pedidopago/protodb")
+	reflectPackage = protogen.GoImportPath("reflect")
+	fmtPackage     = protogen.GoImportPath("fmt")
+)
+
+func main() {
+	protogen.Options{}.Run(func(gen *protogen.Plugin) error {
+		for _, f := range gen.Files {
+			if f.Generate {
+				generateFile(gen, f)
+			}
+		}
+		return nil
+	})
+}
+
+// column is a column of a message (see protodb.ProtoColumns)
+type column struct {
+	name       string
+	field      *protogen.Field
+	meta       map[string]string
+	selectOnly bool
+}
+
+// table is a message with the protodb.table option
+type table struct {
+	message *protogen.Message
+	name    string
+	columns []column
+}
+
+// generateFile generates the <name>.protodb.go file of f (nothing is generated if f has no tables)
+func generateFile(gen *protogen.Plugin, f *protogen.File) *protogen.GeneratedFile {
+	tables := make([]table, 0)
+	var walk func(messages []*protogen.Message)
+	walk = func(messages []*protogen.Message) {
+		for _, m := range messages {
+			if t, ok := messageTable(m); ok {
+				tables = append(tables, t)
+			}
+			walk(m.Messages)
+		}
+	}
+	walk(f.Messages)
+	if len(tables) == 0 {
+		return nil
+	}
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+".protodb.go", f.GoImportPath)
+	g.P("// Code generated by protoc-gen-protodb. DO NOT EDIT.")
+	g.P("// source: ", f.Desc.Path())
+	g.P()
+	g.P("package ", f.GoPackageName)
+	for _, t := range tables {
+		g.P()
+		generateTable(g, t)
+	}
+	return g
+}
+
+// messageTable reads the protodb options of m (false if m has no protodb.table option)
+func messageTable(m *protogen.Message) (table, bool) {
+	columns, ok := protodb.ProtoColumns(m.Desc)
+	if !ok {
+		return table{}, false
+	}
+	tableOpts, _ := proto.GetExtension(m.Desc.Options(), protodbpb.E_Table).(*protodbpb.TableOptions)
+	t := table{
+		message: m,
+		name:    tableOpts.GetName(),
+	}
+	if t.name == "" {
+		t.name = tableOpts.GetSelectTable()
+	}
+	fields := make(map[protoreflect.FieldNumber]*protogen.Field)
+	for _, field := range m.Fields {
+		fields[field.Desc.Number()] = field
+	}
+	for _, c := range columns {
+		v := protodb.TagData{Meta: c.Meta}
+		field, ok := fields[c.Field.Number()]
+		if !ok {
+			continue
+		}
+		t.columns = append(t.columns, column{
+			name:       c.Name,
+			field:      field,
+			meta:       c.Meta,
+			selectOnly: v.MetaFlag("selectonly"),
+		})
+	}
+	return t, true
+}
+
+func isTimestamp(field *protogen.Field) bool {
+	return field.Message != nil && field.Message.Desc.FullName() == "google.protobuf.Timestamp"
+}
+
+func generateTable(g *protogen.GeneratedFile, t table) {
+	name := t.message.GoIdent.GoName
+	names := make([]string, 0, len(t.columns))
+	joins := make([]string, 0)
+	for _, c := range t.columns {
+		names = append(names, strconv.Quote(c.name))
+		if v := c.meta["join"]; v != "" {
+			joins = append(joins, strconv.Quote(v))
+		}
+	}
+	g.P("// ", name, "Table is the table of ", name, ".")
+	g.P("const ", name, "Table = ", strconv.Quote(t.name))
+	g.P()
+	g.P("// ", name, "Columns are the columns of ", name, ".")
+	g.P("var ", name, "Columns = []string{", strings.Join(names, ", "), "}")
+	g.P()
+	g.P("// ", name, "Joins are the joins of the columns of ", name, ".")
+	g.P("var ", name, "Joins = []string{", strings.Join(joins, ", "), "}")
+	g.P()
+	g.P("var _", name, "_columnMeta = []map[string]string{")
+	for _, c := range t.columns {
+		keys := make([]string, 0, len(c.meta))
+		for k := range c.meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, strconv.Quote(k)+": "+strconv.Quote(c.meta[k]))
+		}
+		g.P("{", strings.Join(pairs, ", "), "},")
+	}
+	g.P("}")
+	g.P()
+
+	g.P("// ProtodbColumns implements ", protodbPackage.Ident("Model"), ".")
+	g.P("func (x *", name, ") ProtodbColumns(selecting bool) []", protodbPackage.Ident("TagData"), " {")
+	g.P("columns := make([]", protodbPackage.Ident("TagData"), ", 0, ", len(t.columns), ")")
+	for i, c := range t.columns {
+		if c.selectOnly {
+			g.P("if selecting {")
+		}
+		g.P("columns = append(columns, ", protodbPackage.Ident("TagData"), "{Name: ", strconv.Quote(c.name),
+			", Meta: _", name, "_columnMeta[", i, "], FieldName: ", strconv.Quote(c.field.GoName),
+			", FieldValue: ", reflectPackage.Ident("ValueOf"), "(&x.", c.field.GoName, ").Elem()})")
+		if c.selectOnly {
+			g.P("}")
+		}
+	}
+	g.P("return columns")
+	g.P("}")
+	g.P()
+
+	g.P("// ProtodbScan implements ", protodbPackage.Ident("Model"), ".")
+	g.P("func (x *", name, ") ProtodbScan(columns []string) ([]interface{}, error) {")
+	g.P("dest := make([]interface{}, len(columns))")
+	g.P("for i, name := range columns {")
+	g.P("switch name {")
+	for _, c := range t.columns {
+		dst := "&x." + c.field.GoName
+		if c.field.Desc.Kind() == protoreflect.EnumKind && !c.field.Desc.IsList() && !c.field.Desc.IsMap() {
+			dst = "(*int32)(" + dst + ")"
+		}
+		g.P("case ", strconv.Quote(c.name), ":")
+		g.P("dest[i] = ", protodbPackage.Ident("FieldScanner"), "(name, ", dst, ")")
+	}
+	g.P("default:")
+	g.P("return nil, ", fmtPackage.Ident("Errorf"), "(", strconv.Quote(name+" has no column %q"), ", name)")
+	g.P("}")
+	g.P("}")
+	g.P("return dest, nil")
+	g.P("}")
+	g.P()
+
+	g.P("// ProtodbValue implements ", protodbPackage.Ident("Model"), ".")
+	g.P("func (x *", name, ") ProtodbValue(column string) (interface{}, bool) {")
+	g.P("switch column {")
+	for _, c := range t.columns {
+		g.P("case ", strconv.Quote(c.name), ":")
+		generateValue(g, c)
+	}
+	g.P("}")
+	g.P("return nil, false")
+	g.P("}")
+	g.P()
+	g.P("var _ ", protodbPackage.Ident("Model"), " = (*", name, ")(nil)")
+}
+
+// generateValue generates the value of the column c like the CRUD functions resolve the values
+// of the extracted columns (the "nilval" and "zeronil" subtags are applied)
+func generateValue(g *protogen.GeneratedFile, c column) {
+	field := "x." + c.field.GoName
+	nilval := "nil"
+	if v, ok := c.meta["nilval"]; ok {
+		nilval = strconv.Quote(v)
+	}
+	nilable := nilableField(c.field)
+	if nilable {
+		g.P("if ", field, " == nil {")
+		g.P("return ", nilval, ", true")
+		g.P("}")
+	}
+	if v := (protodb.TagData{Meta: c.meta}); v.MetaBool("zeronil", false) && !nilable {
+		g.P("if ", field, " == ", zeroValue(c.field), " {")
+		g.P("return nil, true")
+		g.P("}")
+	}
+	if isTimestamp(c.field) && !c.field.Desc.IsList() && !c.field.Desc.IsMap() {
+		g.P("return ", field, ".AsTime(), true")
+		return
+	}
+	g.P("return ", field, ", true")
+}
+
+// nilableField returns true if the Go field of the proto field is a pointer, a slice or a map
+func nilableField(field *protogen.Field) bool {
+	fd := field.Desc
+	if fd.IsList() || fd.IsMap() || fd.Message() != nil || fd.Kind() == protoreflect.BytesKind {
+		return true
+	}
+	return fd.HasPresence() && fd.ContainingOneof() == nil
+}
+
+// zeroValue returns the zero value of the (non-nilable) Go field of the proto field
+func zeroValue(field *protogen.Field) string {
+	switch field.Desc.Kind() {
+	case protoreflect.StringKind:
+		return `""`
+	case protoreflect.BoolKind:
+		return "false"
+	}
+	return "0"
+}
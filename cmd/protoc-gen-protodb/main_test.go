@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/pedidopago/protodb/protodbpb"
+	"github.com/pedidopago/protodb/protodbpb/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// generate runs the plugin on the file fd (and its imports), like protoc does
+func generate(t *testing.T, fd protoreflect.FileDescriptor) *pluginpb.CodeGeneratorResponse {
+	files := make([]*descriptorpb.FileDescriptorProto, 0)
+	seen := make(map[string]bool)
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		for i := 0; i < fd.Imports().Len(); i++ {
+			add(fd.Imports().Get(i).FileDescriptor)
+		}
+		files = append(files, protodesc.ToFileDescriptorProto(fd))
+	}
+	add(fd)
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{fd.Path()},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile:      files,
+	})
+	require.NoError(t, err)
+	for _, f := range gen.Files {
+		if f.Generate {
+			generateFile(gen, f)
+		}
+	}
+	resp := gen.Response()
+	require.Empty(t, resp.GetError())
+	return resp
+}
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		file   protoreflect.FileDescriptor
+		golden string
+	}{
+		// the generated code of model.proto is also used by the protodb tests
+		{testpb.File_protodbpb_testpb_model_proto, "../../protodbpb/testpb/model.protodb.go"},
+		{testpb.File_protodbpb_testpb_test_proto, "testdata/test.protodb.go.golden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file.Path(), func(t *testing.T) {
+			resp := generate(t, tt.file)
+			require.Len(t, resp.File, 1)
+			content := resp.File[0].GetContent()
+			if *update {
+				require.NoError(t, ioutil.WriteFile(tt.golden, []byte(content), 0644))
+			}
+			golden, err := ioutil.ReadFile(tt.golden)
+			require.NoError(t, err)
+			require.Equal(t, string(golden), content)
+		})
+	}
+}
+
+func TestGenerateNoTables(t *testing.T) {
+	resp := generate(t, protodbpb.File_protodbpb_options_proto)
+	require.Empty(t, resp.File)
+}
+
+func TestGenerateFilename(t *testing.T) {
+	resp := generate(t, testpb.File_protodbpb_testpb_model_proto)
+	require.Equal(t, "protodbpb/testpb/model.protodb.go", resp.File[0].GetName())
+}
@@ -0,0 +1,104 @@
+package protodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+// DeleteColumnScan uses db_delete, dbdelete, db (in this order) to map the table and key columns of a delete
+func DeleteColumnScan(v interface{}, tags ...string) ColumnsResult {
+	if r, ok := protoColumnScan(v, false); ok {
+		return r
+	}
+	tags = append(tags, "db_delete", "dbdelete", "db")
+	result, err := extract(v, map[string]string{"db": ","}, tags...)
+	return ColumnsResult{
+		Err:     err,
+		Columns: result,
+		typ:     structType(v),
+	}
+}
+
+// WithUnfilteredDelete allows DeleteContext to run a DELETE without a WHERE clause.
+func WithUnfilteredDelete(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowUnfilteredDelete, true)
+}
+
+// DeleteContext executes a DeleteColumnScan on item (with reflection) to determine which table is used
+// to delete data. Use qfn to apply where filters (and other query modifiers). If qfn is nil, the
+// columns tagged with "key" (or "pk") are used to filter the rows to be deleted.
+// A delete without any WHERE clause is refused unless ctx is created with WithUnfilteredDelete.
+// If the item has a column flagged with the "softdelete" subtag (a column of the root table, not of a
+// nested or joined struct), the rows are not deleted: the column
+// is set to the current time instead (UPDATE ... SET deleted_at = ? WHERE ... AND deleted_at IS NULL)
+// and the rows are hidden by GetContext and SelectContext (see WithDeleted, WithHardDelete and RestoreContext).
+// Example:
+//      type Example struct {
+//         ID   int    `db:"id,table=agents,key"`
+//         Name string `db:"name"`
+//      }
+//      // DELETE FROM agents WHERE id = ?
+//      protodb.DeleteContext(ctx, db, &Example{ID: 1}, nil)
+//      // soft delete:
+//      type SoftExample struct {
+//         ID        int        `db:"id,table=agents,key"`
+//         DeletedAt *time.Time `db:"deleted_at,softdelete"`
+//      }
+//      // UPDATE agents SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL
+//      protodb.DeleteContext(ctx, db, &SoftExample{ID: 1}, nil)
+func DeleteContext(ctx context.Context, dbtx sqlx.ExecerContext, item interface{}, qfn func(rq squirrel.DeleteBuilder) squirrel.DeleteBuilder) (sql.Result, error) {
+	dbtx = Execer(ctx, dbtx)
+	// 1 - extract ther underlying type
+	value := reflect.ValueOf(item)
+	if isNilSafe(value) {
+		return nil, errors.New("item is nil")
+	}
+	if isTypeSliceOrSlicePointer(value.Type()) {
+		return nil, errors.New("DeleteContext: cannot delete a slice or a slice pointer")
+	}
+	columns := DeleteColumnScan(value)
+	if err := columns.Err; err != nil {
+		return nil, err
+	}
+	tname := columns.GetTableNameMeta(ctx)
+	if tname == "" {
+		return nil, errors.New("(delete) subtag 'table' not found")
+	}
+	dialect := DialectOf(ctx, dbtx)
+	rq := dialect.Builder().Delete(tname)
+	if qfn != nil {
+		rq = qfn(rq)
+	} else {
+		for _, v := range columns.Columns {
+			if v.Name != "-" && v.Name != "" && v.IsKey() {
+				rq = rq.Where(squirrel.Eq{dialect.column(v.Name): resolveValue(v)})
+			}
+		}
+	}
+	if !builderHasParts(rq, "WhereParts") && !contextFlag(ctx, allowUnfilteredDelete) {
+		return nil, errors.New("(delete) refusing to delete without a WHERE clause (see WithUnfilteredDelete)")
+	}
+	if col, ok := columns.softDeleteColumn(); ok && !contextFlag(ctx, hardDelete) {
+		deletedAt := now(ctx)
+		rawq, args, err := softDeleteBuilder(dialect, tname, col, timeValue(col.FieldValue, deletedAt), rq).ToSql()
+		if err != nil {
+			return nil, err
+		}
+		result, err := execContext(ctx, dbtx, rawq, args...)
+		if err != nil {
+			return nil, err
+		}
+		setTimeValue(col.FieldValue, deletedAt)
+		return result, nil
+	}
+	rawq, args, err := rq.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return execContext(ctx, dbtx, rawq, args...)
+}
@@ -0,0 +1,65 @@
+package protodb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	item := &struct {
+		ID   int    `db:"id,table=agents,key"`
+		Name string `db:"name"`
+	}{
+		ID:   7,
+		Name: "Mole Person",
+	}
+
+	// key columns are used when qfn is nil
+	mock.ExpectExec("DELETE FROM agents WHERE id = \\?").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	result, err := protodb.DeleteContext(context.Background(), db, item, nil)
+	require.NoError(t, err)
+	ra, err := result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), ra)
+
+	// qfn replaces the key filter
+	mock.ExpectExec("DELETE FROM agents WHERE name=\\?").WithArgs("Mole Person").WillReturnResult(sqlmock.NewResult(0, 2))
+	_, err = protodb.DeleteContext(context.Background(), db, item, func(rq squirrel.DeleteBuilder) squirrel.DeleteBuilder {
+		return rq.Where("name=?", item.Name)
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteContextUnfiltered(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	item := &struct {
+		ID   int    `db:"id,table=agents"`
+		Name string `db:"name"`
+	}{}
+
+	_, err := protodb.DeleteContext(context.Background(), db, item, nil)
+	require.Error(t, err)
+	_, err = protodb.DeleteContext(context.Background(), db, item, func(rq squirrel.DeleteBuilder) squirrel.DeleteBuilder {
+		return rq.Limit(10)
+	})
+	require.Error(t, err)
+
+	mock.ExpectExec("DELETE FROM agents$").WillReturnResult(sqlmock.NewResult(0, 3))
+	_, err = protodb.DeleteContext(protodb.WithUnfilteredDelete(context.Background()), db, item, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
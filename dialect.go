@@ -0,0 +1,126 @@
+package protodb
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Dialect is the SQL dialect used to build the queries
+type Dialect string
+
+const (
+	// MySQL is the MySQL/MariaDB dialect (default)
+	MySQL Dialect = "mysql"
+	// PostgreSQL is the PostgreSQL dialect
+	PostgreSQL Dialect = "postgres"
+)
+
+// DialectFromDriver returns the Dialect of a database/sql driver name.
+// Unknown drivers are treated as MySQL.
+func DialectFromDriver(name string) Dialect {
+	switch name {
+	case "postgres", "pgx", "pq", "cloudsqlpostgres", "nrpostgres":
+		return PostgreSQL
+	}
+	return MySQL
+}
+
+// WithDialect forces the Dialect used by the queries executed with ctx (instead of detecting it
+// by the driver name of the *sqlx.DB or *sqlx.Tx).
+func WithDialect(ctx context.Context, d Dialect) context.Context {
+	return context.WithValue(ctx, dialectKey, d)
+}
+
+// DialectOf returns the Dialect set by WithDialect or, if not set, the Dialect of the driver
+// of dbtx (*sqlx.DB, *sqlx.Tx or anything with a DriverName() string method).
+func DialectOf(ctx context.Context, dbtx interface{}) Dialect {
+	if ctx != nil {
+		if d, ok := ctx.Value(dialectKey).(Dialect); ok && d != "" {
+			return d
+		}
+	}
+	return DialectFromDriver(driverName(dbtx))
+}
+
+// PlaceholderFormat returns the squirrel placeholder format of the dialect ("?" or "$1")
+func (d Dialect) PlaceholderFormat() squirrel.PlaceholderFormat {
+	if d == PostgreSQL {
+		return squirrel.Dollar
+	}
+	return squirrel.Question
+}
+
+// Builder returns a squirrel.StatementBuilderType with the placeholder format of the dialect
+func (d Dialect) Builder() squirrel.StatementBuilderType {
+	return squirrel.StatementBuilder.PlaceholderFormat(d.PlaceholderFormat())
+}
+
+// QuoteIdent quotes an identifier (each part of a dotted identifier is quoted)
+//      MySQL:      schema.table -> `schema`.`table`
+//      PostgreSQL: schema.table -> "schema"."table"
+func (d Dialect) QuoteIdent(ident string) string {
+	q := "`"
+	if d == PostgreSQL {
+		q = `"`
+	}
+	parts := strings.Split(ident, ".")
+	for i, p := range parts {
+		parts[i] = q + strings.Replace(p, q, q+q, -1) + q
+	}
+	return strings.Join(parts, ".")
+}
+
+// ILike returns a case insensitive LIKE expression (ILIKE on PostgreSQL). MySQL uses LIKE, since
+// the default collations are case insensitive.
+func (d Dialect) ILike(column string, value interface{}) squirrel.Sqlizer {
+	if d == PostgreSQL {
+		return squirrel.ILike{column: value}
+	}
+	return squirrel.Like{column: value}
+}
+
+// SupportsReturning returns true if the dialect supports INSERT ... RETURNING
+func (d Dialect) SupportsReturning() bool {
+	return d == PostgreSQL
+}
+
+// Returning returns the RETURNING clause of the columns
+func (d Dialect) Returning(columns ...string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = d.column(c)
+	}
+	return "RETURNING " + strings.Join(quoted, ", ")
+}
+
+var simpleIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedWords are common column names that must be quoted in MySQL or PostgreSQL
+var reservedWords = map[string]struct{}{
+	"all": {}, "and": {}, "as": {}, "asc": {}, "between": {}, "by": {}, "case": {}, "check": {},
+	"column": {}, "constraint": {}, "create": {}, "current_date": {}, "current_time": {},
+	"current_timestamp": {}, "current_user": {}, "default": {}, "delete": {}, "desc": {},
+	"distinct": {}, "drop": {}, "else": {}, "end": {}, "exists": {}, "false": {}, "from": {},
+	"grant": {}, "group": {}, "having": {}, "in": {}, "index": {}, "insert": {}, "interval": {},
+	"into": {}, "is": {}, "join": {}, "key": {}, "keys": {}, "like": {}, "limit": {}, "not": {},
+	"null": {}, "offset": {}, "on": {}, "or": {}, "order": {}, "primary": {}, "range": {},
+	"references": {}, "rows": {}, "select": {}, "set": {}, "table": {}, "then": {}, "to": {},
+	"true": {}, "union": {}, "unique": {}, "update": {}, "user": {}, "using": {}, "values": {},
+	"when": {}, "where": {}, "with": {},
+}
+
+// column quotes a column name generated by protodb (insert/update/delete) if it is a reserved word.
+// Other names are kept as is, so PostgreSQL folds them to lower case like MySQL matches them
+// case insensitively.
+func (d Dialect) column(name string) string {
+	if !simpleIdent.MatchString(name) {
+		return name
+	}
+	if _, ok := reservedWords[strings.ToLower(name)]; !ok {
+		return name
+	}
+	return d.QuoteIdent(name)
+}
@@ -0,0 +1,72 @@
+package protodb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialectOf(t *testing.T) {
+	mdb, _ := ptesting.MockDBMySQL(t)
+	defer mdb.Close()
+	pdb, _ := ptesting.MockDBPostgres(t)
+	defer pdb.Close()
+
+	ctx := context.Background()
+	require.Equal(t, protodb.MySQL, protodb.DialectOf(ctx, mdb))
+	require.Equal(t, protodb.PostgreSQL, protodb.DialectOf(ctx, pdb))
+	require.Equal(t, protodb.PostgreSQL, protodb.DialectOf(protodb.WithDialect(ctx, protodb.PostgreSQL), mdb))
+	require.Equal(t, protodb.PostgreSQL, protodb.DialectFromDriver("pgx"))
+	require.Equal(t, protodb.MySQL, protodb.DialectFromDriver("unknown"))
+}
+
+func TestDialectQuoteIdent(t *testing.T) {
+	require.Equal(t, "`orders`.`order`", protodb.MySQL.QuoteIdent("orders.order"))
+	require.Equal(t, `"orders"."order"`, protodb.PostgreSQL.QuoteIdent("orders.order"))
+	require.Equal(t, `"a""b"`, protodb.PostgreSQL.QuoteIdent(`a"b`))
+
+	sql, args, err := protodb.PostgreSQL.ILike("name", "a%").ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "name ILIKE ?", sql)
+	require.Equal(t, []interface{}{"a%"}, args)
+	sql, _, err = protodb.MySQL.ILike("name", "a%").ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "name LIKE ?", sql)
+}
+
+func TestPostgresPlaceholders(t *testing.T) {
+	db, mock := ptesting.MockDBPostgres(t)
+	defer db.Close()
+
+	item := &struct {
+		ID    int    `db:"id" dbupdate:"id;table=orders"`
+		Order int    `db:"order" dbupdate:"order"`
+		Name  string `db:"name" dbupdate:"name"`
+	}{ID: 1, Order: 2, Name: "x"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE orders SET "order" = $1, name = $2 WHERE id = $3`)).
+		WithArgs(2, "x", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.UpdateContext(context.Background(), db, item, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", item.ID)
+	}, "id")
+	require.NoError(t, err)
+
+	dest := &struct {
+		ID   int    `db:"id,table=orders"`
+		Name string `db:"name"`
+	}{}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name FROM orders WHERE name ILIKE $1`)).
+		WithArgs("x%").WillReturnRows(mock.NewRows([]string{"id", "name"}).AddRow(1, "xy"))
+	require.NoError(t, protodb.GetContext(context.Background(), db, dest, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where(protodb.PostgreSQL.ILike("name", "x%"))
+	}))
+	require.Equal(t, "xy", dest.Name)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
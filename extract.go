@@ -4,11 +4,30 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 )
 
 var TagSeparator = ";"
 
+// fieldMeta is the parsed tag of a (possibly nested) struct field
+type fieldMeta struct {
+	index       []int // index path from the root struct (like reflect.Type.FieldByIndex)
+	name        string
+	meta        map[string]string
+	fieldName   string
+	recursiveIf *ConditionalContextKey
+}
+
+type metaCacheKey struct {
+	typ  reflect.Type
+	tags string
+}
+
+// metaCache caches the []fieldMeta of each metaCacheKey
+var metaCache sync.Map
+
 func extract(v interface{}, tagSeparators map[string]string, tags ...string) ([]TagData, error) {
 	var vval reflect.Value
 	if v == nil {
@@ -19,25 +38,136 @@ func extract(v interface{}, tagSeparators map[string]string, tags ...string) ([]
 	} else {
 		vval = reflect.ValueOf(v)
 	}
-	x := make([]TagData, 0)
-	err := extractStep(vval, tagSeparators, tags, &x, nil)
-	return x, err
+	for vval.Kind() == reflect.Ptr {
+		vval = vval.Elem()
+	}
+	if kind := vval.Kind(); kind != reflect.Struct {
+		return nil, fmt.Errorf("invalid source kind %v", kind.String())
+	}
+	return resolveFields(vval, cachedTypeMeta(vval.Type(), tagSeparators, tags)), nil
+}
+
+// cachedTypeMeta returns the (cached) typeMeta of t
+func cachedTypeMeta(t reflect.Type, tagSeparators map[string]string, tags []string) []fieldMeta {
+	key := metaCacheKey{
+		typ:  t,
+		tags: metaCacheTags(tagSeparators, tags),
+	}
+	fields, ok := metaCache.Load(key)
+	if !ok {
+		fields, _ = metaCache.LoadOrStore(key, typeMeta(t, tagSeparators, tags))
+	}
+	return fields.([]fieldMeta)
+}
+
+// metaCacheTags returns a string that identifies the tags and separators used to parse a type
+func metaCacheTags(tagSeparators map[string]string, tags []string) string {
+	b := new(strings.Builder)
+	b.WriteString(strings.Join(tags, "\x00"))
+	keys := make([]string, 0, len(tagSeparators))
+	for k := range tagSeparators {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString("\x01" + k + "\x02" + tagSeparators[k])
+	}
+	return b.String()
 }
 
-func extractStep(v reflect.Value, tagSeparators map[string]string, tags []string, x *[]TagData, valrecursiveIf *ConditionalContextKey) error {
-	kind := v.Kind()
-	switch kind {
-	case reflect.Ptr:
-		return extractStep(v.Elem(), tagSeparators, tags, x, valrecursiveIf)
-	case reflect.Struct: //, reflect.Map:
-		// okay
-	default:
-		return fmt.Errorf("invalid source kind %v", kind.String())
-	}
-	srcn := v.NumField()
-	srcType := v.Type()
+// resolveFields returns the TagData of each field of v (a struct). Fields inside nil pointers are omitted.
+// The Meta maps are copied, so the cached fieldMeta can't be modified by the callers.
+func resolveFields(v reflect.Value, fields []fieldMeta) []TagData {
+	x := make([]TagData, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			continue
+		}
+		x = append(x, TagData{
+			Name:        f.name,
+			Meta:        copyMeta(f.meta),
+			FieldName:   f.fieldName,
+			FieldValue:  fv,
+			FieldIndex:  f.index,
+			RecursiveIf: f.recursiveIf,
+		})
+	}
+	return x
+}
+
+func copyMeta(meta map[string]string) map[string]string {
+	x := make(map[string]string, len(meta))
+	for k, v := range meta {
+		x[k] = v
+	}
+	return x
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but it returns false instead of panicking
+// when a nil pointer is found in the path.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// splitSubtags splits a tag by sep, except inside parentheses (e.g. "total,type=DECIMAL(10,2)")
+func splitSubtags(tag, sep string) []string {
+	x := make([]string, 0, strings.Count(tag, sep)+1)
+	depth, start := 0, 0
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '(':
+			depth++
+		case tag[i] == ')' && depth > 0:
+			depth--
+		case depth == 0 && strings.HasPrefix(tag[i:], sep):
+			x = append(x, tag[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	if depth > 0 {
+		// unbalanced parentheses are split like strings.Split
+		return strings.Split(tag, sep)
+	}
+	return append(x, tag[start:])
+}
+
+// typeMeta parses the tags of every field of t (a struct type) and of its nested structs
+func typeMeta(t reflect.Type, tagSeparators map[string]string, tags []string) []fieldMeta {
+	x := make([]fieldMeta, 0)
+	typeMetaStep(t, tagSeparators, tags, &x, nil, nil, make(map[reflect.Type]bool))
+	return x
+}
+
+// typeMetaStep appends the fields of t to x. path holds the struct types being expanded: a recursive
+// type (e.g. a Next *Node field of Node) is not expanded again, so its columns are not repeated.
+func typeMetaStep(t reflect.Type, tagSeparators map[string]string, tags []string, x *[]fieldMeta, index []int, valrecursiveIf *ConditionalContextKey, path map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || path[t] {
+		return
+	}
+	path[t] = true
+	defer delete(path, t)
+	srcn := t.NumField()
 	for i := 0; i < srcn; i++ {
-		srcfield := srcType.Field(i)
+		srcfield := t.Field(i)
+		findex := make([]int, len(index)+1)
+		copy(findex, index)
+		findex[len(index)] = i
 		skipRecursive := false
 		var recursiveIf *ConditionalContextKey
 		for _, tag := range tags {
@@ -48,13 +178,13 @@ func extractStep(v reflect.Value, tagSeparators map[string]string, tags []string
 			// replace ''' with `
 			tag = strings.Replace(tag, "'''", "`", -1)
 			if tt, ok := srcfield.Tag.Lookup(tag); ok {
-				tms := strings.Split(tt, ts)
-				item := TagData{
-					Name:        tms[0],
-					Meta:        make(map[string]string),
-					FieldName:   srcfield.Name,
-					FieldValue:  v.Field(i),
-					RecursiveIf: valrecursiveIf,
+				tms := splitSubtags(tt, ts)
+				item := fieldMeta{
+					index:       findex,
+					name:        tms[0],
+					meta:        make(map[string]string),
+					fieldName:   srcfield.Name,
+					recursiveIf: valrecursiveIf,
 				}
 				if len(tms) > 1 {
 					for _, vf := range tms[1:] {
@@ -68,19 +198,20 @@ func extractStep(v reflect.Value, tagSeparators map[string]string, tags []string
 								rif := IfKey(keyval[1])
 								recursiveIf = &rif
 							default:
-								item.Meta[keyval[0]] = keyval[1]
+								item.meta[keyval[0]] = keyval[1]
 							}
 						} else {
 							switch keyval[0] {
 							case "norecursive", "skiprecursive":
 								skipRecursive = true
+							default:
+								// flags without a value (e.g. "key") are stored as "" (see MetaFlag)
+								item.meta[strings.TrimSpace(keyval[0])] = ""
 							}
 						}
 					}
 				}
 				*x = append(*x, item)
-				// parts := strings.Split(tt, ",")
-				// if strings.TrimSpace(parts[0]) != "-" {}
 				break
 			}
 		}
@@ -91,12 +222,8 @@ func extractStep(v reflect.Value, tagSeparators map[string]string, tags []string
 				if vif == nil {
 					vif = valrecursiveIf
 				}
-				if err := extractStep(v.Field(i), tagSeparators, tags, x, vif); err != nil {
-					//TODO: return recursive fields error without breaking higher levels
-					_ = err
-				}
+				typeMetaStep(srcfield.Type, tagSeparators, tags, x, findex, vif, path)
 			}
 		}
 	}
-	return nil
 }
@@ -55,3 +55,78 @@ func TestExtract(t *testing.T) {
 		assert.Equal(t, expected[i].Name, v.Name)
 	}
 }
+
+func TestExtractNested(t *testing.T) {
+	type Inner struct {
+		City string `db:"city"`
+	}
+	type Node struct {
+		ID    int    `db:"id,table=nodes,key"`
+		Inner *Inner `db:"-"`
+		Next  *Node
+	}
+
+	// fields of nil pointers are omitted
+	tagd, err := extract(&Node{}, map[string]string{"db": ","}, "db")
+	require.NoError(t, err)
+	require.Len(t, tagd, 2)
+	assert.True(t, tagd[0].IsKey())
+
+	// the self-referential pointer is not expanded (its columns would be repeated)
+	n := &Node{ID: 1, Inner: &Inner{City: "Recife"}, Next: &Node{ID: 2}}
+	n.Next.Next = n
+	tagd, err = extract(n, map[string]string{"db": ","}, "db")
+	require.NoError(t, err)
+	require.Len(t, tagd, 3)
+	assert.Equal(t, "city", tagd[2].Name)
+	assert.Equal(t, []int{1, 0}, tagd[2].FieldIndex)
+	assert.Equal(t, "Recife", tagd[2].FieldValue.Interface())
+
+	// the Meta maps are not shared with the cache
+	tagd[0].Meta["key"] = "false"
+	tagd, err = extract(n, map[string]string{"db": ","}, "db")
+	require.NoError(t, err)
+	assert.True(t, tagd[0].IsKey())
+
+	_, err = extract((*Node)(nil), nil, "db")
+	require.Error(t, err)
+}
+
+type benchExtractItem struct {
+	ID        int64   `db:"id,table=orders o,select=o.id"`
+	StoreID   string  `db:"store_id,select=o.store_id"`
+	Customer  string  `db:"customer,select=c.name AS customer,join=LEFT JOIN customers c ON c.id=o.customer_id"`
+	Status    int32   `db:"status,skipzero=true"`
+	Total     float64 `db:"total"`
+	Notes     *string `db:"notes,skipnil=true"`
+	CreatedAt string  `db:"created_at,skipzero=true"`
+	UpdatedAt string  `db:"updated_at,skipzero=true"`
+}
+
+func BenchmarkExtract(b *testing.B) {
+	item := &benchExtractItem{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := extract(item, map[string]string{"db": ","}, "db_select", "dbselect", "db"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkExtractUncached parses the tags on every call (the behavior before the metadata cache)
+func BenchmarkExtractUncached(b *testing.B) {
+	item := &benchExtractItem{}
+	tags := []string{"db_select", "dbselect", "db"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v := reflect.ValueOf(item).Elem()
+		resolveFields(v, typeMeta(v.Type(), map[string]string{"db": ","}, tags))
+	}
+}
+
+func TestSplitSubtags(t *testing.T) {
+	assert.Equal(t, []string{"total", "type=DECIMAL(10,2)", "default=0"}, splitSubtags("total,type=DECIMAL(10,2),default=0", ","))
+	assert.Equal(t, []string{"a", "select=COALESCE(b, c)"}, splitSubtags("a;select=COALESCE(b, c)", ";"))
+	assert.Equal(t, []string{"a", "b(", "c"}, splitSubtags("a,b(,c", ","))
+	assert.Equal(t, []string{""}, splitSubtags("", ","))
+}
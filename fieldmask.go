@@ -0,0 +1,144 @@
+package protodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// UpdateMaskContext updates the columns of item selected by the paths of mask (e.g. the update_mask of
+// a gRPC Update request). The columns are always set, even if zero (skipnil and skipzero are ignored).
+// Use qfn to apply where filters (and other query modifiers).
+//
+// A path is matched with the fields of item by the protobuf name, the protobuf JSON name, the json tag
+// or the Go name of each field. A path of a nested message (e.g. "address") selects all columns of the
+// nested struct ("address.street", "address.city"...); the columns of a nil nested struct are set to NULL.
+// A path can also be a column name. "*" selects all columns. The columns of a message with the protodb
+// options (see ProtoColumnScan) are matched in the same way (select_only fields are not updated).
+// An error is returned if the mask is empty or if a path does not map to any column.
+// Example:
+//      type Customer struct {
+//         Id      string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty" db:"id,table=customers"`
+//         Name    string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty" db:"name"`
+//         Address *Address `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty" db:"-"`
+//      }
+//      type Address struct {
+//         Street string `protobuf:"bytes,1,opt,name=street,proto3" json:"street,omitempty" db:"address_street"`
+//      }
+//      // UPDATE customers SET name = ?, address_street = ? WHERE id = ?
+//      protodb.UpdateMaskContext(ctx, db, req.Customer, &fieldmaskpb.FieldMask{Paths: []string{"name", "address.street"}}, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+//          return rq.Where("id = ?", req.Customer.Id)
+//      })
+func UpdateMaskContext(ctx context.Context, dbtx sqlx.ExecerContext, item interface{}, mask *fieldmaskpb.FieldMask, qfn func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder) (sql.Result, error) {
+	value := reflect.ValueOf(item)
+	if isNilSafe(value) {
+		return nil, errors.New("item is nil")
+	}
+	if len(mask.GetPaths()) == 0 {
+		return nil, errors.New("(update) empty field mask")
+	}
+	t := reflectx.Deref(value.Type())
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("(update) invalid item kind %v", t.Kind())
+	}
+	fields := maskFields(t)
+	selected := make(map[string]struct{})
+	for _, path := range mask.GetPaths() {
+		found := false
+		for _, f := range fields {
+			if v := (TagData{Name: f.name, Meta: f.meta}); v.Name == "-" || v.Name == "" || v.MetaFlag("selectonly") {
+				continue
+			}
+			if path == "*" || path == f.name || matchMaskPath(t, f.index, strings.Split(path, ".")) {
+				selected[fmt.Sprint(f.index)] = struct{}{}
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("(update) field mask path '%s' does not map to any column", path)
+		}
+	}
+	// the columns inside nil nested structs are not scanned by UpdateColumnScan
+	root := reflect.Indirect(value)
+	nullColumns := make([]string, 0)
+	for _, f := range fields {
+		if _, ok := selected[fmt.Sprint(f.index)]; !ok {
+			continue
+		}
+		if _, ok := fieldByIndex(root, f.index); !ok {
+			nullColumns = append(nullColumns, f.name)
+		}
+	}
+	dialect := DialectOf(ctx, Execer(ctx, dbtx))
+	return updateContext(ctx, dbtx, item, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		for _, name := range nullColumns {
+			rq = rq.Set(dialect.column(name), nil)
+		}
+		if qfn != nil {
+			rq = qfn(rq)
+		}
+		return rq
+	}, nil, func(v TagData) bool {
+		_, ok := selected[fmt.Sprint(v.FieldIndex)]
+		return ok
+	})
+}
+
+// maskFields returns the fields of t matched by the field mask paths: the protodb options of a message
+// with the protodb.table option (see ProtoColumnScan) or the update struct tags
+func maskFields(t reflect.Type) []fieldMeta {
+	if m, ok := reflect.New(t).Interface().(proto.Message); ok {
+		if fields, ok := cachedProtoMeta(t, m.ProtoReflect().Descriptor()); ok {
+			return fields
+		}
+	}
+	return cachedTypeMeta(t, map[string]string{"db": ","}, updateScanTags)
+}
+
+// matchMaskPath returns true if the field mask path (split by ".") selects the field index of t
+// (the path can be a prefix of the field path)
+func matchMaskPath(t reflect.Type, index []int, path []string) bool {
+	for _, i := range index {
+		if len(path) == 0 {
+			return true
+		}
+		t = reflectx.Deref(t)
+		sf := t.Field(i)
+		t = sf.Type
+		if sf.Anonymous {
+			// embedded structs do not add a path segment
+			continue
+		}
+		if !matchMaskName(sf, path[0]) {
+			return false
+		}
+		path = path[1:]
+	}
+	return len(path) == 0
+}
+
+// matchMaskName returns true if name is the protobuf name, the protobuf JSON name, the json name or
+// the Go name of the field
+func matchMaskName(sf reflect.StructField, name string) bool {
+	if name == sf.Name {
+		return true
+	}
+	for _, opt := range strings.Split(sf.Tag.Get("protobuf"), ",") {
+		if opt == "name="+name || opt == "json="+name {
+			return true
+		}
+	}
+	if jsonName := strings.Split(sf.Tag.Get("json"), ",")[0]; jsonName != "" && jsonName == name {
+		return true
+	}
+	return false
+}
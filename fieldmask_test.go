@@ -0,0 +1,87 @@
+package protodb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	"github.com/pedidopago/protodb/protodbpb/testpb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+type maskAddress struct {
+	Street  string `protobuf:"bytes,1,opt,name=street,proto3" json:"street,omitempty" db:"address_street"`
+	ZipCode string `protobuf:"bytes,2,opt,name=zip_code,json=zipCode,proto3" json:"zip_code,omitempty" db:"address_zip"`
+}
+
+type maskCustomer struct {
+	Id          string       `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty" db:"id,table=customers"`
+	DisplayName string       `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty" db:"name,skipzero=true"`
+	Score       int32        `protobuf:"varint,3,opt,name=score,proto3" json:"score,omitempty" db:"score"`
+	Address     *maskAddress `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty" db:"-"`
+}
+
+func TestUpdateMaskContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+	item := &maskCustomer{Id: "c1", Address: &maskAddress{Street: "Main St", ZipCode: "123"}}
+	qfn := func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", item.Id)
+	}
+
+	// zero values are set; proto, json and nested paths
+	mock.ExpectExec("UPDATE customers SET name = \\?, score = \\?, address_zip = \\? WHERE id = \\?").
+		WithArgs("", int32(0), "123", "c1").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.UpdateMaskContext(ctx, db, item, &fieldmaskpb.FieldMask{Paths: []string{"display_name", "score", "address.zipCode"}}, qfn)
+	require.NoError(t, err)
+
+	// a nested message path selects all of its columns
+	mock.ExpectExec("UPDATE customers SET address_street = \\?, address_zip = \\? WHERE id = \\?").
+		WithArgs("Main St", "123", "c1").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = protodb.UpdateMaskContext(ctx, db, item, &fieldmaskpb.FieldMask{Paths: []string{"address"}}, qfn)
+	require.NoError(t, err)
+
+	// the columns of a nil message are set to NULL
+	item.Address = nil
+	mock.ExpectExec("UPDATE customers SET address_street = \\?, address_zip = \\? WHERE id = \\?").
+		WithArgs(nil, nil, "c1").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = protodb.UpdateMaskContext(ctx, db, item, &fieldmaskpb.FieldMask{Paths: []string{"address"}}, qfn)
+	require.NoError(t, err)
+
+	_, err = protodb.UpdateMaskContext(ctx, db, item, &fieldmaskpb.FieldMask{Paths: []string{"name", "unknown"}}, qfn)
+	require.EqualError(t, err, "(update) field mask path 'unknown' does not map to any column")
+	_, err = protodb.UpdateMaskContext(ctx, db, item, &fieldmaskpb.FieldMask{Paths: []string{"address.unknown"}}, qfn)
+	require.Error(t, err)
+	_, err = protodb.UpdateMaskContext(ctx, db, item, nil, qfn)
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateMaskContextProto(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	// the paths of a message with the protodb options are matched with its columns
+	order := &testpb.Order{Id: "o1", Status: testpb.OrderStatus_ORDER_STATUS_PAID, TotalCents: 0}
+	mock.ExpectExec("^"+regexp.QuoteMeta("UPDATE orders SET status = ?, total = ? WHERE id = ?")+"$").
+		WithArgs(testpb.OrderStatus_ORDER_STATUS_PAID, int64(0), "o1").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.UpdateMaskContext(context.Background(), db, order, &fieldmaskpb.FieldMask{Paths: []string{"status", "totalCents"}}, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", order.Id)
+	})
+	require.NoError(t, err)
+
+	// select_only and skipped fields are not columns
+	_, err = protodb.UpdateMaskContext(context.Background(), db, order, &fieldmaskpb.FieldMask{Paths: []string{"customer_name"}}, nil)
+	require.Error(t, err)
+	_, err = protodb.UpdateMaskContext(context.Background(), db, order, &fieldmaskpb.FieldMask{Paths: []string{"note"}}, nil)
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,156 @@
+package protodb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Generator generates the value of an empty column with the "gen" subtag (e.g. `db:"id,gen=uuid"`)
+type Generator func(ctx context.Context) (interface{}, error)
+
+var (
+	generatorsMu sync.RWMutex
+	generators   = map[string]Generator{
+		"uuid":  genUUID,
+		"ulid":  genULID,
+		"ksuid": genKSUID,
+	}
+)
+
+// RegisterGenerator registers (or replaces) the generator name, used by the "gen" subtag.
+// The builtin generators are "uuid" (random UUID v4), "ulid" and "ksuid" (strings).
+// Example:
+//      protodb.RegisterGenerator("snowflake", func(ctx context.Context) (interface{}, error) {
+//          return node.Generate().Int64(), nil
+//      })
+//      type Example struct {
+//         ID   int64  `db:"id,table=agents,gen=snowflake"`
+//         Name string `db:"name"`
+//      }
+func RegisterGenerator(name string, gen Generator) {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+	generators[name] = gen
+}
+
+func generator(name string) (Generator, bool) {
+	generatorsMu.RLock()
+	defer generatorsMu.RUnlock()
+	gen, ok := generators[name]
+	return gen, ok
+}
+
+// generateValue sets the zero field of a column with the "gen" subtag to a generated value
+func generateValue(ctx context.Context, v TagData) error {
+	name, ok := v.MetaStringCheck("gen")
+	if !ok || !v.FieldValue.IsValid() || !v.FieldValue.IsZero() {
+		return nil
+	}
+	gen, ok := generator(name)
+	if !ok {
+		return fmt.Errorf("(insert) generator '%s' of column %s not found", name, v.Name)
+	}
+	if !v.FieldValue.CanSet() {
+		return fmt.Errorf("(insert) field %s is not settable", v.FieldName)
+	}
+	x, err := gen(ctx)
+	if err != nil {
+		return fmt.Errorf("(insert) failed to generate %s: %w", v.Name, err)
+	}
+	rv := reflect.ValueOf(x)
+	// int -> string is convertible (as a rune), but it is never the intended result
+	intToString := v.FieldValue.Kind() == reflect.String && rv.IsValid() && rv.Kind() != reflect.String && rv.Kind() != reflect.Slice
+	if !rv.IsValid() || intToString || !rv.Type().ConvertibleTo(v.FieldValue.Type()) {
+		return fmt.Errorf("(insert) generator '%s' returned %T (field %s is %v)", name, x, v.FieldName, v.FieldValue.Type())
+	}
+	v.FieldValue.Set(rv.Convert(v.FieldValue.Type()))
+	return nil
+}
+
+// genUUID generates a random (version 4) UUID
+func genUUID(ctx context.Context) (interface{}, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	ulidMu      sync.Mutex
+	ulidLastMs  uint64
+	ulidLastRnd [10]byte
+)
+
+// genULID generates a ULID (48 bits of unix time in milliseconds and 80 random bits). The ULIDs
+// generated in the same millisecond are monotonic (the random part is incremented).
+func genULID(ctx context.Context) (interface{}, error) {
+	ms := uint64(now(ctx).UnixNano() / int64(time.Millisecond))
+	ulidMu.Lock()
+	defer ulidMu.Unlock()
+	var rnd [10]byte
+	if ms == ulidLastMs {
+		rnd = ulidLastRnd
+		for i := len(rnd) - 1; i >= 0; i-- {
+			rnd[i]++
+			if rnd[i] != 0 {
+				break
+			}
+			if i == 0 {
+				return nil, fmt.Errorf("ulid overflow")
+			}
+		}
+	} else if _, err := rand.Read(rnd[:]); err != nil {
+		return nil, err
+	}
+	ulidLastMs, ulidLastRnd = ms, rnd
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint16(b[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:], uint32(ms))
+	copy(b[6:], rnd[:])
+	// 128 bits -> 26 characters of 5 bits (the first one has only 3 bits)
+	out := make([]byte, 26)
+	n := new(big.Int).SetBytes(b)
+	mask := big.NewInt(31)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 5)
+	}
+	return string(out), nil
+}
+
+const (
+	base62     = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	ksuidEpoch = 1400000000
+)
+
+// genKSUID generates a KSUID (32 bits of time since the KSUID epoch and 128 random bits encoded
+// as 27 base62 characters)
+func genKSUID(ctx context.Context) (interface{}, error) {
+	b := make([]byte, 20)
+	binary.BigEndian.PutUint32(b, uint32(now(ctx).Unix()-ksuidEpoch))
+	if _, err := rand.Read(b[4:]); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 27)
+	n := new(big.Int).SetBytes(b)
+	base := big.NewInt(62)
+	mod := new(big.Int)
+	for i := 26; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = base62[mod.Int64()]
+	}
+	return string(out), nil
+}
@@ -0,0 +1,92 @@
+package protodb_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertGeneratedKeys(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	uuidRe := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidRe := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	ksuidRe := regexp.MustCompile(`^[0-9A-Za-z]{27}$`)
+
+	item := &struct {
+		ID    string `db:"id,table=agents,gen=uuid"`
+		Ref   string `db:"ref,gen=ksuid"`
+		Name  string `db:"name"`
+		Fixed string `db:"fixed,gen=uuid"`
+	}{Name: "a", Fixed: "keep"}
+	mock.ExpectExec("INSERT INTO agents \\(id,ref,name,fixed\\)").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "a", "keep").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.InsertContext(context.Background(), db, item, nil)
+	require.NoError(t, err)
+	require.Regexp(t, uuidRe, item.ID)
+	require.Regexp(t, ksuidRe, item.Ref)
+	require.Equal(t, "keep", item.Fixed)
+
+	// every element of a slice gets a (sortable) ulid
+	type ulidAgent struct {
+		ID   string `db:"id,table=agents,gen=ulid"`
+		Name string `db:"name"`
+	}
+	items := []*ulidAgent{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	mock.ExpectExec("INSERT INTO agents \\(id,name\\)").WillReturnResult(sqlmock.NewResult(0, 3))
+	_, err = protodb.InsertContext(context.Background(), db, &items, nil)
+	require.NoError(t, err)
+	for i, v := range items {
+		require.Regexp(t, ulidRe, v.ID)
+		if i > 0 {
+			require.Less(t, items[i-1].ID, v.ID)
+		}
+	}
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegisterGenerator(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	next := int64(100)
+	protodb.RegisterGenerator("test_seq", func(ctx context.Context) (interface{}, error) {
+		next++
+		return next, nil
+	})
+	protodb.RegisterGenerator("test_fail", func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	item := &struct {
+		ID   int64  `db:"id,table=agents,gen=test_seq"`
+		Name string `db:"name"`
+	}{Name: "a"}
+	mock.ExpectExec("INSERT INTO agents \\(id,name\\)").WithArgs(int64(101), "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.InsertContext(context.Background(), db, item, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(101), item.ID)
+
+	_, err = protodb.InsertContext(context.Background(), db, &struct {
+		ID string `db:"id,table=agents,gen=test_fail"`
+	}{}, nil)
+	require.Error(t, err)
+	_, err = protodb.InsertContext(context.Background(), db, &struct {
+		ID string `db:"id,table=agents,gen=unknown"`
+	}{}, nil)
+	require.Error(t, err)
+	_, err = protodb.InsertContext(context.Background(), db, &struct {
+		ID []int `db:"id,table=agents,gen=uuid"`
+	}{}, nil)
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/base64"
+	"errors"
 	"strings"
 
 	"github.com/pedidopago/protodb"
@@ -166,20 +167,28 @@ func wrap2(ctx context.Context, err error) error {
 	// and return a *status.Status.Err()
 
 	// sql errors
-	if protodb.IsNotFound(err) {
-		nfe := err.(*protodb.NotFoundError)
+	var nfe *protodb.NotFoundError
+	if errors.As(err, &nfe) {
 		return StatusError(codes.NotFound, nfe.Name, xdfromctx(ctx))
 	}
-	if strings.Contains(err.Error(), sql.ErrNoRows.Error()) {
+	if errors.Is(err, sql.ErrNoRows) || strings.Contains(err.Error(), sql.ErrNoRows.Error()) {
 		return StatusError(codes.NotFound, strings.Replace(err.Error(), sql.ErrNoRows.Error(), "", -1), xdfromctx(ctx))
 	}
-	if protodb.IsQueryError(err) {
-		qerr := err.(*protodb.QueryError)
+	var qerr *protodb.QueryError
+	if errors.As(err, &qerr) {
 		if qerr.Err != nil {
 			return StatusError(codes.Internal, qerr.Message, xdfromctx(ctx)+" query(id): "+qerr.Query+"; "+qerr.Err.Error())
 		}
 		return StatusError(codes.Internal, qerr.Message, xdfromctx(ctx)+" query(id): "+qerr.Query)
 	}
+	switch {
+	case protodb.IsDuplicateKey(err):
+		return StatusError(codes.AlreadyExists, "already exists", xdfromctx(ctx)+" "+err.Error())
+	case protodb.IsForeignKey(err), protodb.IsCheckViolation(err):
+		return StatusError(codes.FailedPrecondition, "failed precondition", xdfromctx(ctx)+" "+err.Error())
+	case protodb.IsDeadlock(err), protodb.IsLockTimeout(err), protodb.IsSerializationFailure(err), protodb.IsStaleObject(err):
+		return StatusError(codes.Aborted, "aborted", xdfromctx(ctx)+" "+err.Error())
+	}
 	//TODO: try parse more common errors
 	return StatusError(codes.Internal, "internal error", xdfromctx(ctx)+" "+err.Error())
 }
@@ -0,0 +1,40 @@
+package grpce
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pedidopago/protodb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWrapWrappedErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"not found", &protodb.NotFoundError{Name: "agents", Err: sql.ErrNoRows}, codes.NotFound},
+		{"no rows", sql.ErrNoRows, codes.NotFound},
+		{"query", protodb.QueryErr("failed", "q1", errors.New("boom")), codes.Internal},
+		{"duplicate key", &protodb.DuplicateKeyError{Err: errors.New("dup")}, codes.AlreadyExists},
+		{"foreign key", &protodb.ForeignKeyError{Err: errors.New("fk")}, codes.FailedPrecondition},
+		{"deadlock", &protodb.DeadlockError{Err: errors.New("deadlock")}, codes.Aborted},
+		{"stale object", &protodb.StaleObjectError{Name: "orders", Version: 3}, codes.Aborted},
+		{"other", errors.New("boom"), codes.Internal},
+	}
+	for _, tt := range tests {
+		for _, err := range []error{tt.err, fmt.Errorf("svc: %w", tt.err), fmt.Errorf("handler: %w", fmt.Errorf("svc: %w", tt.err))} {
+			st, ok := status.FromError(wrap2(context.Background(), err))
+			assert.True(t, ok, tt.name)
+			assert.Equal(t, tt.code, st.Code(), tt.name)
+		}
+	}
+	st, _ := status.FromError(Wrap(fmt.Errorf("svc: %w", protodb.NotFound("agents"))))
+	assert.Equal(t, "agents", st.Message())
+}
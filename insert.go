@@ -3,8 +3,13 @@ package protodb
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
@@ -12,6 +17,9 @@ import (
 
 // InsertColumnScan uses db_insert, dbinsert, insert, db (in this order) to map columns and values to be inserted
 func InsertColumnScan(v interface{}, tags ...string) ColumnsResult {
+	if r, ok := protoColumnScan(v, false); ok {
+		return r
+	}
 	tags = append(tags, "db_insert", "dbinsert", "insert", "db")
 	result, err := extract(v, map[string]string{"db": ","}, tags...)
 	return ColumnsResult{
@@ -20,92 +28,483 @@ func InsertColumnScan(v interface{}, tags ...string) ColumnsResult {
 	}
 }
 
+// WithInsertBatchSize limits the number of rows of each INSERT statement executed by InsertContext
+// when inserting a slice. The driver placeholder limit is always respected.
+func WithInsertBatchSize(ctx context.Context, rows int) context.Context {
+	return context.WithValue(ctx, insertBatchSize, rows)
+}
+
+// BatchResult is the sql.Result of a multi-row InsertContext. The rows may be split into
+// multiple INSERT statements (chunks); Results holds the result of each chunk, in order.
+type BatchResult struct {
+	Results []sql.Result
+}
+
+// LastInsertId returns the LastInsertId of the first chunk.
+func (r *BatchResult) LastInsertId() (int64, error) {
+	if len(r.Results) < 1 {
+		return 0, errors.New("no rows inserted")
+	}
+	return r.Results[0].LastInsertId()
+}
+
+// RowsAffected returns the sum of the rows affected by every chunk.
+func (r *BatchResult) RowsAffected() (int64, error) {
+	var total int64
+	for _, v := range r.Results {
+		n, err := v.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
 // InsertContext executes a InsertColumnScan on dest (with reflection) to determine which tableand rows are used
 // to insert data. Use qfn to apply where filters (and other query modifiers).
+//
+// If items is a slice (or a pointer to a slice), every element is inserted with a multi-row INSERT. The columns
+// are the same for every row: a column is only omitted if it is skipped (skipnil, skipzero...) in every row, and
+// rows that skip an included column insert DEFAULT. Large slices are split in chunks that respect the driver
+// placeholder limit (and WithInsertBatchSize). In this case, the returned sql.Result is a *BatchResult and qfn
+// is applied to each chunk.
+//
+// Generated columns:
+//   - "autoinc": the column is omitted when zero. On MySQL, the LastInsertId of a single row insert is
+//                written back into the field. On PostgreSQL, the column is added to a RETURNING clause.
+//   - "returning": (PostgreSQL) the column is added to a RETURNING clause. The returned values (ids, defaults,
+//                  timestamps...) are scanned back into the item (or each slice element).
+//   - "autocreate", "autoupdate": if zero, the field is set to the current time (see WithClock) before
+//                                 the insert. Supported types: time.Time, *time.Time, sql.NullTime,
+//                                 *timestamppb.Timestamp and integers (unix time).
+//   - "gen": if zero, the field is set to a value generated by the named generator ("uuid", "ulid", "ksuid"
+//            or registered with RegisterGenerator) before the insert (e.g. `db:"id,table=agents,gen=ulid"`).
+// Example:
+//      type Example struct {
+//         ID        int64     `db:"id,table=agents,autoinc"`
+//         Name      string    `db:"name"`
+//         CreatedAt time.Time `db:"created_at,skipzero=true,returning"`
+//      }
 func InsertContext(ctx context.Context, dbtx sqlx.ExecerContext, items interface{}, qfn func(rq squirrel.InsertBuilder) squirrel.InsertBuilder) (sql.Result, error) {
+	return insertContext(ctx, dbtx, items, qfn, nil)
+}
+
+// insertContext executes the INSERT statements of items; suffix (optional) is applied to each chunk
+// before qfn
+func insertContext(ctx context.Context, dbtx sqlx.ExecerContext, items interface{}, qfn func(rq squirrel.InsertBuilder) squirrel.InsertBuilder, suffix func(plan *insertPlan, rq squirrel.InsertBuilder) (squirrel.InsertBuilder, error)) (sql.Result, error) {
+	dbtx = Execer(ctx, dbtx)
 	// 1 - extract ther underlying type
 	value := reflect.ValueOf(items)
 	if err := errIfNotAPointerOrNil(value); err != nil {
 		return nil, err
 	}
-	var rq squirrel.InsertBuilder
-	if !isTypeSliceOrSlicePointer(value.Type()) {
-		// Insert a single row
-		columns := InsertColumnScan(value)
-		if err := columns.Err; err != nil {
-			return nil, err
+	isSlice := isTypeSliceOrSlicePointer(value.Type())
+	var rows []reflect.Value
+	if isSlice {
+		sliceIter := reflect.Indirect(value)
+		if sliceIter.Len() < 1 {
+			return nil, errors.New("needs at least one row to insert")
 		}
-		tname := columns.GetTableNameMeta(ctx)
-		if tname == "" {
-			return nil, errors.New("(insert) subtag 'table' not found")
+		rows = make([]reflect.Value, sliceIter.Len())
+		for i := range rows {
+			rows[i] = sliceIter.Index(i)
 		}
-		rq = squirrel.Insert(tname)
-		colNames := []string{}
-		vals := []interface{}{}
-		for _, v := range columns.Columns {
-			if v.Name != "-" && v.Name != "" {
-				if !skipInsertSingleRow(v) {
-					colNames = append(colNames, v.Name)
-					vals = append(vals, resolveValue(v))
-				}
+	} else {
+		// Insert a single row
+		rows = []reflect.Value{value}
+	}
+	plan, err := newInsertPlan(ctx, DialectOf(ctx, dbtx), rows)
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := insertChunkSize(ctx, dbtx, len(plan.columns))
+	result := &BatchResult{}
+	for k, rq := range plan.builders(chunkSize) {
+		if suffix != nil {
+			if rq, err = suffix(plan, rq); err != nil {
+				return nil, err
 			}
 		}
-		rq = rq.Columns(colNames...).Values(vals...)
 		if qfn != nil {
 			rq = qfn(rq)
 		}
-		rawq, args, err := rq.ToSql()
+		var r sql.Result
+		if retcols := plan.returningColumns(); len(retcols) > 0 {
+			keys := plan.returningKeys(retcols)
+			if plan.doNothing && len(keys) == 0 {
+				return nil, errors.New("(upsert) the RETURNING columns of an ON CONFLICT DO NOTHING require inserted key columns to match the rows")
+			}
+			r, err = insertReturning(ctx, dbtx, rq, plan, retcols, keys, k*chunkSize)
+		} else {
+			r, err = insertExec(ctx, dbtx, rq)
+		}
 		if err != nil {
 			return nil, err
 		}
-		return dbtx.ExecContext(ctx, rawq, args...)
+		result.Results = append(result.Results, r)
+	}
+	if !isSlice {
+		if err := plan.setLastInsertId(result.Results[0]); err != nil {
+			return nil, err
+		}
+		return result.Results[0], nil
+	}
+	return result, nil
+}
+
+func insertExec(ctx context.Context, dbtx sqlx.ExecerContext, rq squirrel.InsertBuilder) (sql.Result, error) {
+	rawq, args, err := rq.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return execContext(ctx, dbtx, rawq, args...)
+}
+
+// insertReturning executes the INSERT with a RETURNING clause and scans the returned columns
+// into the rows of the chunk (starting at plan.rows[start]). The returned rows are matched with the
+// inserted rows by the key columns; without keys they are matched by position (PostgreSQL returns
+// the rows in the order of the VALUES).
+func insertReturning(ctx context.Context, dbtx sqlx.ExecerContext, rq squirrel.InsertBuilder, plan *insertPlan, retcols, keys []string, start int) (sql.Result, error) {
+	queryer, ok := dbtx.(sqlx.QueryerContext)
+	if !ok {
+		return nil, errors.New("(insert) RETURNING columns require a sqlx.QueryerContext")
+	}
+	rawq, args, err := rq.Suffix(plan.dialect.Returning(append(append([]string{}, retcols...), keys...)...)).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queryer.QueryContext(ctx, rawq, args...)
+	if err != nil {
+		return nil, ClassifyError(err)
+	}
+	defer rows.Close()
+	pending := plan.rowsByKey(keys, start)
+	result := &returningResult{}
+	for i := start; rows.Next(); i++ {
+		values := make([]interface{}, len(retcols)+len(keys))
+		dest := make([]interface{}, len(values))
+		for j := range values {
+			dest[j] = &values[j]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := i
+		if len(keys) > 0 {
+			k := returningKey(values[len(retcols):])
+			if len(pending[k]) == 0 {
+				return nil, errors.New("(insert) RETURNING returned a row that does not match an inserted row")
+			}
+			row, pending[k] = pending[k][0], pending[k][1:]
+		}
+		if row >= len(plan.rows) {
+			return nil, errors.New("(insert) RETURNING returned more rows than inserted")
+		}
+		for j, name := range retcols {
+			fv, ok := plan.rows[row].column(name)
+			if !ok || !fv.CanAddr() {
+				return nil, fmt.Errorf("(insert) cannot set RETURNING column %s", name)
+			}
+			if err := (&protoField{name: name, v: fv}).Scan(values[j]); err != nil {
+				return nil, err
+			}
+		}
+		if result.affected == 0 {
+			result.firstID, result.hasID = plan.rows[row].autoincID()
+		}
+		result.affected++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, ClassifyError(err)
 	}
-	sliceIter := reflect.Indirect(value)
-	if sliceIter.Len() < 1 {
-		return nil, errors.New("needs at least one row to insert")
+	return result, nil
+}
+
+// returningKeys returns the inserted key columns (the conflict columns, see UpsertContext) that are
+// used to match the RETURNING rows with the inserted rows (nil if they are returned or not inserted)
+func (p *insertPlan) returningKeys(retcols []string) []string {
+	keys := conflictColumns(p.scan.Columns)
+	for _, name := range keys {
+		for _, r := range retcols {
+			if r == name {
+				return nil
+			}
+		}
+		if p.columnIndex(name) < 0 {
+			return nil
+		}
+	}
+	return keys
+}
+
+// columnIndex returns the index of the inserted column name (-1 if it is not inserted)
+func (p *insertPlan) columnIndex(name string) int {
+	for i, v := range p.columns {
+		if v.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// rowsByKey returns the indexes of the rows (starting at start) by the returningKey of the inserted
+// values of keys
+func (p *insertPlan) rowsByKey(keys []string, start int) map[string][]int {
+	x := make(map[string][]int)
+	if len(keys) == 0 {
+		return x
 	}
-	for i := 0; i < sliceIter.Len(); i++ {
-		columns := InsertColumnScan(value)
+	for i := start; i < len(p.values); i++ {
+		values := make([]interface{}, len(keys))
+		for j, name := range keys {
+			values[j] = p.values[i][p.columnIndex(name)]
+		}
+		k := returningKey(values)
+		x[k] = append(x[k], i)
+	}
+	return x
+}
+
+// returningKey returns a comparable key of the (inserted or returned) values of the key columns
+func returningKey(values []interface{}) string {
+	b := new(strings.Builder)
+	for _, v := range values {
+		if dv, ok := v.(driver.Valuer); ok {
+			if x, err := dv.Value(); err == nil {
+				v = x
+			}
+		}
+		b.WriteString("\x00")
+		if bs, ok := v.([]byte); ok {
+			b.WriteString(string(bs))
+			continue
+		}
+		if t, ok := v.(time.Time); ok {
+			b.WriteString(t.UTC().Format(time.RFC3339Nano))
+			continue
+		}
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b.WriteString(strconv.FormatInt(rv.Int(), 10))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			b.WriteString(strconv.FormatUint(rv.Uint(), 10))
+		case reflect.String:
+			b.WriteString(rv.String())
+		default:
+			fmt.Fprint(b, v)
+		}
+	}
+	return b.String()
+}
+
+// returningResult is the sql.Result of an INSERT ... RETURNING
+type returningResult struct {
+	affected int64
+	firstID  int64
+	hasID    bool
+}
+
+// LastInsertId returns the "autoinc" column of the first row (if it is an integer)
+func (r *returningResult) LastInsertId() (int64, error) {
+	if !r.hasID {
+		return 0, errors.New("LastInsertId is not supported by this driver")
+	}
+	return r.firstID, nil
+}
+
+func (r *returningResult) RowsAffected() (int64, error) {
+	return r.affected, nil
+}
+
+// insertPlan holds the table, columns and values of the rows to be inserted
+type insertPlan struct {
+	dialect Dialect
+	table   string
+	scan    ColumnsResult   // the InsertColumnScan of the first row
+	rows    []ColumnsResult // the InsertColumnScan of each row
+	// doNothing is set by an upsert with ON CONFLICT DO NOTHING (the skipped rows are not returned)
+	doNothing bool
+	columns   []TagData       // the TagData of the first row that includes each column
+	values    [][]interface{} // one slice of values (aligned with columns) per row
+}
+
+func newInsertPlan(ctx context.Context, dialect Dialect, rows []reflect.Value) (*insertPlan, error) {
+	plan := &insertPlan{dialect: dialect}
+	// columns are kept in the order of the struct fields
+	order := make([]string, 0)
+	included := make(map[string]*TagData)
+	scans := make([]ColumnsResult, len(rows))
+	t := now(ctx)
+	for i, row := range rows {
+		columns := InsertColumnScan(row)
 		if err := columns.Err; err != nil {
 			return nil, err
 		}
+		for _, v := range columns.Columns {
+			if err := generateValue(ctx, v); err != nil {
+				return nil, err
+			}
+			if v.FieldValue.IsValid() && v.FieldValue.IsZero() && isAutoTime(v, "autocreate", "autoupdate") {
+				setTimeValue(v.FieldValue, t)
+			}
+		}
 		if i == 0 {
-			// start query and insert columns
-			tname := columns.GetTableNameMeta(ctx)
-			if tname == "" {
+			plan.scan = columns
+			plan.table = columns.GetTableNameMeta(ctx)
+			if plan.table == "" {
 				return nil, errors.New("(insert) subtag 'table' not found")
 			}
-			rq = squirrel.Insert(tname)
-			colNames := []string{}
-			for _, v := range columns.Columns {
-				if v.Name == "-" || v.Name == "" {
-					colNames = append(colNames, v.Name)
-				}
+		}
+		for j, v := range columns.Columns {
+			if v.Name == "-" || v.Name == "" {
+				continue
+			}
+			td, seen := included[v.Name]
+			if !seen {
+				order = append(order, v.Name)
 			}
-			rq = rq.Columns(colNames...)
+			if td == nil && !skipInsertSingleRow(v) {
+				td = &columns.Columns[j]
+			}
+			included[v.Name] = td
+		}
+		scans[i] = columns
+	}
+	plan.rows = scans
+	colIndex := make(map[string]int)
+	for _, name := range order {
+		if td := included[name]; td != nil {
+			colIndex[name] = len(plan.columns)
+			plan.columns = append(plan.columns, *td)
+		}
+	}
+	plan.values = make([][]interface{}, len(rows))
+	for i, columns := range scans {
+		vals := make([]interface{}, len(plan.columns))
+		for j := range vals {
+			vals[j] = squirrel.Expr("DEFAULT")
 		}
-		vals := []interface{}{}
 		for _, v := range columns.Columns {
-			if v.Name != "-" && v.Name != "" {
-				vals = append(vals, resolveValue(v))
+			if v.Name == "-" || v.Name == "" {
+				continue
+			}
+			if j, ok := colIndex[v.Name]; ok && !skipInsertSingleRow(v) {
+				if isAutoTime(v, "autocreate", "autoupdate") {
+					vals[j] = timeColumnValue(v)
+				} else {
+					vals[j] = resolveValue(v)
+				}
 			}
 		}
-		rq = rq.Values(vals...)
+		plan.values[i] = vals
 	}
-	if qfn != nil {
-		rq = qfn(rq)
+	return plan, nil
+}
+
+func (p *insertPlan) columnNames() []string {
+	names := make([]string, len(p.columns))
+	for i, v := range p.columns {
+		names[i] = p.dialect.column(v.Name)
 	}
-	rawq, args, err := rq.ToSql()
-	if err != nil {
-		return nil, err
+	return names
+}
+
+// returningColumns returns the columns tagged with "autoinc" or "returning" if the dialect
+// supports INSERT ... RETURNING
+func (p *insertPlan) returningColumns() []string {
+	if !p.dialect.SupportsReturning() {
+		return nil
 	}
-	return dbtx.ExecContext(ctx, rawq, args...)
+	cols := make([]string, 0)
+	for _, v := range p.scan.Columns {
+		if v.Name != "-" && v.Name != "" && (v.MetaFlag("autoinc") || v.MetaFlag("returning")) {
+			cols = append(cols, v.Name)
+		}
+	}
+	return cols
+}
+
+// setLastInsertId sets the "autoinc" field of a single row insert with result.LastInsertId()
+// (MySQL). It is a no-op if the field was already populated (e.g. by RETURNING).
+func (p *insertPlan) setLastInsertId(result sql.Result) error {
+	if len(p.rows) != 1 || len(p.returningColumns()) > 0 {
+		return nil
+	}
+	for _, v := range p.rows[0].Columns {
+		if !v.MetaFlag("autoinc") || !v.FieldValue.IsValid() || !v.FieldValue.IsZero() {
+			continue
+		}
+		id, err := result.LastInsertId()
+		if err != nil || id == 0 {
+			// the driver does not support it or no row was inserted (upsert)
+			return nil
+		}
+		return setIntValue(v.FieldValue, id)
+	}
+	return nil
+}
+
+// builders returns one InsertBuilder per chunk of (at most) chunkSize rows
+func (p *insertPlan) builders(chunkSize int) []squirrel.InsertBuilder {
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	colNames := p.columnNames()
+	rqs := make([]squirrel.InsertBuilder, 0, len(p.values)/chunkSize+1)
+	for start := 0; start < len(p.values); start += chunkSize {
+		end := start + chunkSize
+		if end > len(p.values) {
+			end = len(p.values)
+		}
+		rq := p.dialect.Builder().Insert(p.table).Columns(colNames...)
+		for _, vals := range p.values[start:end] {
+			rq = rq.Values(vals...)
+		}
+		rqs = append(rqs, rq)
+	}
+	return rqs
+}
+
+// insertChunkSize returns the max number of rows of a single INSERT statement
+func insertChunkSize(ctx context.Context, dbtx interface{}, ncolumns int) int {
+	if ncolumns < 1 {
+		ncolumns = 1
+	}
+	size := placeholderLimit(dbtx) / ncolumns
+	if v, ok := ctx.Value(insertBatchSize).(int); ok && v > 0 && v < size {
+		size = v
+	}
+	return size
+}
+
+// autoincID returns the value of the "autoinc" column if it is an integer
+func (r ColumnsResult) autoincID() (int64, bool) {
+	for _, v := range r.Columns {
+		if !v.MetaFlag("autoinc") {
+			continue
+		}
+		fv := reflect.Indirect(v.FieldValue)
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return fv.Int(), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return int64(fv.Uint()), true
+		}
+		return 0, false
+	}
+	return 0, false
 }
 
 func skipInsertSingleRow(v TagData) bool {
 	if !v.FieldValue.IsValid() {
 		return true
 	}
+	if v.MetaFlag("autoinc") && v.FieldValue.IsZero() {
+		// let the database generate the value
+		return true
+	}
 	if isNilSafe(v.FieldValue) {
 		return v.MetaBool("skipnil", false)
 	}
@@ -1,8 +1,14 @@
 package protodb
 
 import (
+	"context"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	ptesting "github.com/pedidopago/protodb/testing"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,3 +41,188 @@ func TestInsertColumnScan(t *testing.T) {
 	require.NoError(t, cres.Err)
 	require.Equal(t, "John", cres.Columns[0].FieldValue.Interface())
 }
+
+func TestInsertContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	item := &struct {
+		ID   int    `db:"id,table=users,skipzero=true"`
+		Name string `db:"name"`
+	}{
+		Name: "Tom",
+	}
+	mock.ExpectExec("INSERT INTO users \\(name\\) VALUES \\(\\?\\)").WithArgs("Tom").WillReturnResult(sqlmock.NewResult(10, 1))
+	result, err := InsertContext(context.Background(), db, item, nil)
+	require.NoError(t, err)
+	id, err := result.LastInsertId()
+	require.NoError(t, err)
+	require.Equal(t, int64(10), id)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertContextSlice(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	type user struct {
+		ID    int    `db:"id,table=users,skipzero=true"`
+		Name  string `db:"name"`
+		Score *int   `db:"score,skipnil=true"`
+		Notes string `db:"-"`
+	}
+	score := 5
+	rows := []*user{
+		{Name: "Tom"},
+		{ID: 3, Name: "John", Score: &score},
+		{Name: "Anne"},
+	}
+
+	ctx := WithInsertBatchSize(context.Background(), 2)
+	mock.ExpectExec("INSERT INTO users \\(id,name,score\\) VALUES \\(DEFAULT,\\?,DEFAULT\\),\\(\\?,\\?,\\?\\)").
+		WithArgs("Tom", 3, "John", 5).WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("INSERT INTO users \\(id,name,score\\) VALUES \\(DEFAULT,\\?,DEFAULT\\)$").
+		WithArgs("Anne").WillReturnResult(sqlmock.NewResult(4, 1))
+	result, err := InsertContext(ctx, db, &rows, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	bresult, ok := result.(*BatchResult)
+	require.True(t, ok)
+	require.Len(t, bresult.Results, 2)
+	ra, err := result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), ra)
+	id, err := result.LastInsertId()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), id)
+
+	_, err = InsertContext(ctx, db, &[]*user{}, nil)
+	require.Error(t, err)
+}
+
+func TestInsertChunkSize(t *testing.T) {
+	db, _ := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	require.Equal(t, 65535/4, insertChunkSize(context.Background(), db, 4))
+	require.Equal(t, 100, insertChunkSize(WithInsertBatchSize(context.Background(), 100), db, 4))
+	require.Equal(t, 999/3, insertChunkSize(context.Background(), sqlx.NewDb(db.DB, "sqlite3"), 3))
+}
+
+func TestInsertContextAutoinc(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	item := &struct {
+		ID   int64  `db:"id,table=users,autoinc"`
+		Name string `db:"name"`
+	}{
+		Name: "Tom",
+	}
+	mock.ExpectExec("INSERT INTO users \\(name\\) VALUES \\(\\?\\)").WithArgs("Tom").WillReturnResult(sqlmock.NewResult(42, 1))
+	_, err := InsertContext(context.Background(), db, item, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), item.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertContextReturning(t *testing.T) {
+	db, mock := ptesting.MockDBPostgres(t)
+	defer db.Close()
+
+	type user struct {
+		ID        int64  `db:"id,table=users,autoinc"`
+		Name      string `db:"name"`
+		CreatedAt string `db:"created_at,skipzero=true,returning"`
+	}
+	rows := []user{{Name: "Tom"}, {Name: "John"}}
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (name) VALUES ($1),($2) RETURNING id, created_at")).
+		WithArgs("Tom", "John").
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}).AddRow(7, "2021-01-01").AddRow(8, "2021-01-02"))
+	result, err := InsertContext(context.Background(), db, &rows, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Equal(t, int64(7), rows[0].ID)
+	require.Equal(t, int64(8), rows[1].ID)
+	require.Equal(t, "2021-01-02", rows[1].CreatedAt)
+	ra, err := result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), ra)
+	id, err := result.LastInsertId()
+	require.NoError(t, err)
+	require.Equal(t, int64(7), id)
+}
+
+func TestInsertContextReturningKeys(t *testing.T) {
+	db, mock := ptesting.MockDBPostgres(t)
+	defer db.Close()
+
+	type user struct {
+		ID        string `db:"id,table=users,key"`
+		CreatedAt string `db:"created_at,skipzero=true,returning"`
+	}
+	// the returned rows are matched with the inserted rows by the key, not by position
+	rows := []user{{ID: "a"}, {ID: "b"}}
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (id) VALUES ($1),($2) RETURNING created_at, id")).
+		WithArgs("a", "b").
+		WillReturnRows(mock.NewRows([]string{"created_at", "id"}).AddRow("2021-01-02", "b").AddRow("2021-01-01", "a"))
+	_, err := InsertContext(context.Background(), db, &rows, nil)
+	require.NoError(t, err)
+	require.Equal(t, "2021-01-01", rows[0].CreatedAt)
+	require.Equal(t, "2021-01-02", rows[1].CreatedAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (id) VALUES ($1) RETURNING created_at, id")).
+		WithArgs("c").
+		WillReturnRows(mock.NewRows([]string{"created_at", "id"}).AddRow("2021-01-03", "x"))
+	_, err = InsertContext(context.Background(), db, &user{ID: "c"}, nil)
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertContextReturningPointers(t *testing.T) {
+	db, mock := ptesting.MockDBPostgres(t)
+	defer db.Close()
+
+	type user struct {
+		ID        *int64     `db:"id,table=users,autoinc"`
+		Name      string     `db:"name"`
+		CreatedAt *time.Time `db:"created_at,skipnil=true,returning"`
+	}
+	created := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	rows := []user{{Name: "Tom"}, {Name: "John"}}
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (name) VALUES ($1),($2) RETURNING id, created_at")).
+		WithArgs("Tom", "John").
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}).AddRow(7, created).AddRow(8, nil))
+	_, err := InsertContext(context.Background(), db, &rows, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.NotNil(t, rows[0].ID)
+	require.Equal(t, int64(7), *rows[0].ID)
+	require.Equal(t, int64(8), *rows[1].ID)
+	require.NotNil(t, rows[0].CreatedAt)
+	require.True(t, created.Equal(*rows[0].CreatedAt))
+	require.Nil(t, rows[1].CreatedAt)
+}
+
+func TestInsertContextBareFlags(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	// skipnil, skipzero and zeronil need a value ("skipnil=true"): without one they are ignored
+	item := &struct {
+		ID    int     `db:"id,table=users,skipzero"`
+		Name  string  `db:"name,zeronil"`
+		Score *int    `db:"score,skipnil"`
+		Email *string `db:"email,skipnil=true"`
+	}{}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users (id,name,score) VALUES (?,?,?)")).
+		WithArgs(0, "", nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err := InsertContext(context.Background(), db, item, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,138 @@
+package protodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// WithTransformFuncs sets the TransformFunc map used by Transform on each row scanned by a Cursor
+// (SelectIterContext and SelectEachContext).
+func WithTransformFuncs(ctx context.Context, funcMap map[string]TransformFunc) context.Context {
+	return context.WithValue(ctx, transformFuncs, funcMap)
+}
+
+// Cursor iterates over the rows of SelectIterContext. It must be closed after use.
+// Example:
+//      cur, err := protodb.SelectIterContext(ctx, db, &Order{}, nil)
+//      if err != nil {
+//          return err
+//      }
+//      defer cur.Close()
+//      for cur.Next() {
+//          item := &Order{}
+//          if err := cur.Scan(item); err != nil {
+//              return err
+//          }
+//      }
+//      return cur.Err()
+type Cursor struct {
+	ctx     context.Context
+	rows    *sqlx.Rows
+	funcMap map[string]TransformFunc
+}
+
+// Next prepares the next row to be read by Scan. It returns false when there are no more rows
+// or if an error happened (see Err).
+func (c *Cursor) Next() bool {
+	return c.rows.Next()
+}
+
+// Scan reads the current row into dest (a pointer to a struct), then runs remap and Transform.
+func (c *Cursor) Scan(dest interface{}) error {
+	if columnsResult, ok := protoColumnScan(dest, true); ok {
+		if err := scanProtoRow(c.ctx, c.rows, columnsResult); err != nil {
+			return err
+		}
+	} else if err := c.rows.StructScan(dest); err != nil {
+		return err
+	}
+	if err := remap(dest); err != nil {
+		return fmt.Errorf("failed to remap: %w", err)
+	}
+	if c.funcMap != nil {
+		if err := Transform(dest, c.funcMap); err != nil {
+			return fmt.Errorf("failed to transform: %w", err)
+		}
+	}
+	return nil
+}
+
+// Err returns the error of the iteration (if any)
+func (c *Cursor) Err() error {
+	return ClassifyError(c.rows.Err())
+}
+
+// Close closes the underlying rows
+func (c *Cursor) Close() error {
+	return c.rows.Close()
+}
+
+// SelectIterContext executes a SelectColumnScan on model (a struct, a pointer to a struct or a pointer to a slice)
+// to determine which table, columns and joins are used, and returns a Cursor to read the rows one by one.
+// Use qfn to apply where filters (and other query modifiers).
+func SelectIterContext(ctx context.Context, dbtx sqlx.QueryerContext, model interface{}, qfn func(rq squirrel.SelectBuilder) squirrel.SelectBuilder) (*Cursor, error) {
+	dbtx = Queryer(ctx, dbtx)
+	columnsResult := modelColumnScan(model)
+	if columnsResult.Err != nil {
+		return nil, columnsResult.Err
+	}
+	rq, err := selectBuilder(ctx, dbtx, columnsResult)
+	if err != nil {
+		return nil, err
+	}
+	if qfn != nil {
+		rq = qfn(rq)
+	}
+	rq = columnsResult.pruneJoins(ctx, rq)
+	q, args, err := rq.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	rows, err := dbtx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return nil, ClassifyError(err)
+	}
+	funcMap, _ := ctx.Value(transformFuncs).(map[string]TransformFunc)
+	return &Cursor{
+		ctx:     ctx,
+		rows:    rows,
+		funcMap: funcMap,
+	}, nil
+}
+
+// SelectEachContext is like SelectIterContext, but it calls fn with each row (a new pointer to the struct
+// type of model). The iteration stops at the first error returned by fn.
+func SelectEachContext(ctx context.Context, dbtx sqlx.QueryerContext, model interface{}, qfn func(rq squirrel.SelectBuilder) squirrel.SelectBuilder, fn func(item interface{}) error) error {
+	value := reflect.ValueOf(model)
+	if isNilSafe(value) {
+		return errors.New("model is nil")
+	}
+	base := reflectx.Deref(value.Type())
+	if base.Kind() == reflect.Slice {
+		base = reflectx.Deref(base.Elem())
+	}
+	cur, err := SelectIterContext(ctx, dbtx, model, qfn)
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+	for cur.Next() {
+		item := reflect.New(base).Interface()
+		if err := cur.Scan(item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	return cur.Close()
+}
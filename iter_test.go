@@ -0,0 +1,67 @@
+package protodb_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+type iterItem struct {
+	ID   int    `db:"id,table=agents"`
+	Name string `db:"name" transform:"upper"`
+}
+
+func TestSelectIterContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, name FROM agents").WillReturnRows(mock.NewRows([]string{"id", "name"}).AddRow(1, "alice").AddRow(2, "anne"))
+
+	ctx := protodb.WithTransformFuncs(context.Background(), map[string]protodb.TransformFunc{
+		"upper": func(v interface{}) interface{} {
+			return strings.ToUpper(v.(string))
+		},
+	})
+	cur, err := protodb.SelectIterContext(ctx, db, &iterItem{}, nil)
+	require.NoError(t, err)
+	defer cur.Close()
+	names := make([]string, 0)
+	for cur.Next() {
+		item := &iterItem{}
+		require.NoError(t, cur.Scan(item))
+		names = append(names, item.Name)
+	}
+	require.NoError(t, cur.Err())
+	require.NoError(t, cur.Close())
+	require.Equal(t, []string{"ALICE", "ANNE"}, names)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelectEachContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, name FROM agents").WillReturnRows(mock.NewRows([]string{"id", "name"}).AddRow(1, "alice").AddRow(2, "anne"))
+	ids := make([]int, 0)
+	require.NoError(t, protodb.SelectEachContext(context.Background(), db, &[]*iterItem{}, nil, func(item interface{}) error {
+		ids = append(ids, item.(*iterItem).ID)
+		return nil
+	}))
+	require.Equal(t, []int{1, 2}, ids)
+
+	errStop := errors.New("stop")
+	mock.ExpectQuery("SELECT id, name FROM agents").WillReturnRows(mock.NewRows([]string{"id", "name"}).AddRow(1, "alice").AddRow(2, "anne"))
+	calls := 0
+	err := protodb.SelectEachContext(context.Background(), db, iterItem{}, nil, func(item interface{}) error {
+		calls++
+		return errStop
+	})
+	require.Equal(t, errStop, err)
+	require.Equal(t, 1, calls)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
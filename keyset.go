@@ -0,0 +1,347 @@
+package protodb
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lann/builder"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrInvalidPageToken is returned when a page token is malformed, was tampered with or
+// was created for a different query.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+var (
+	pageTokenSecretMu sync.RWMutex
+	pageTokenSecret   = randomSecret()
+)
+
+func randomSecret() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("protodb: failed to generate the page token secret: " + err.Error())
+	}
+	return b
+}
+
+// SetPageTokenSecret sets the key used to sign the page tokens of SelectKeysetContext. The default
+// key is random (generated when the program starts), so it must be set when the tokens are shared
+// between processes (e.g. multiple replicas of a service).
+func SetPageTokenSecret(secret []byte) {
+	pageTokenSecretMu.Lock()
+	defer pageTokenSecretMu.Unlock()
+	pageTokenSecret = append([]byte(nil), secret...)
+}
+
+func signPageToken(payload []byte) []byte {
+	pageTokenSecretMu.RLock()
+	defer pageTokenSecretMu.RUnlock()
+	mac := hmac.New(sha256.New, pageTokenSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// KeysetColumn is a sort column of a keyset pagination
+type KeysetColumn struct {
+	Column string // SQL expression of the column (e.g. "o.id"); it must match a selected column
+	Desc   bool
+}
+
+// KeysetRequest is the page requested by SelectKeysetContext
+type KeysetRequest struct {
+	// Columns are the sort columns. If empty, the columns tagged with "keyset=asc" or "keyset=desc"
+	// are used (in the order of the struct fields). The last column must be unique (e.g. the id).
+	Columns   []KeysetColumn
+	PageSize  uint64
+	PageToken string // the next page token returned by the previous call ("" for the first page)
+}
+
+// keysetColumn is a KeysetColumn and the field that holds its value
+type keysetColumn struct {
+	KeysetColumn
+	field TagData
+}
+
+// SelectKeysetContext selects a page of rows sorted by the keyset columns (seek/cursor pagination).
+// dest must be a pointer to a slice. The ORDER BY, LIMIT and the seek predicate of the page token
+// (e.g. "(o.created_at, o.id) > (?, ?)") are added after qfn is applied (the ORDER BY of qfn is discarded).
+// The returned token is empty if there are no more rows; otherwise it can be used as the PageToken
+// of the next call. The tokens are signed (see SetPageTokenSecret) and are only valid for the same table,
+// sort columns and WHERE filters of qfn (ErrInvalidPageToken).
+// Example:
+//      type Order struct {
+//         ID        int64     `db:"id,table=orders o,select=o.id,keyset=asc"`
+//         CreatedAt time.Time `db:"created_at,select=o.created_at"`
+//      }
+//      items := make([]*Order, 0)
+//      next, err := protodb.SelectKeysetContext(ctx, db, &items, protodb.KeysetRequest{
+//          PageSize:  50,
+//          PageToken: req.PageToken,
+//      }, nil)
+func SelectKeysetContext(ctx context.Context, dbtx sqlx.QueryerContext, dest interface{}, req KeysetRequest, qfn func(rq squirrel.SelectBuilder) squirrel.SelectBuilder) (string, error) {
+	if req.PageSize < 1 {
+		return "", errors.New("page size must be greater than 0")
+	}
+	value := reflect.ValueOf(dest)
+	if err := errIfNotAPointerOrNil(value); err != nil {
+		return "", err
+	}
+	if _, err := baseType(value.Type(), reflect.Slice); err != nil {
+		return "", err
+	}
+	columnsResult := modelColumnScan(dest)
+	if columnsResult.Err != nil {
+		return "", columnsResult.Err
+	}
+	columns, err := keysetColumns(columnsResult, req.Columns)
+	if err != nil {
+		return "", err
+	}
+	where, err := keysetWhere(qfn)
+	if err != nil {
+		return "", err
+	}
+	fingerprint := keysetFingerprint(columnsResult.GetTableNameMeta(ctx), where, columns)
+	var seek squirrel.Sqlizer
+	if req.PageToken != "" {
+		values, err := decodePageToken(req.PageToken, fingerprint, columns)
+		if err != nil {
+			return "", err
+		}
+		seek = keysetPredicate(columns, values)
+	}
+	orderBy := make([]string, len(columns))
+	for i, c := range columns {
+		orderBy[i] = c.Column + " ASC"
+		if c.Desc {
+			orderBy[i] = c.Column + " DESC"
+		}
+	}
+	if err := SelectContext(ctx, dbtx, dest, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		if qfn != nil {
+			rq = qfn(rq)
+		}
+		// the rows must be sorted by the keyset columns only
+		rq = builder.Delete(rq, "OrderByParts").(squirrel.SelectBuilder)
+		if seek != nil {
+			rq = rq.Where(seek)
+		}
+		return rq.OrderBy(orderBy...).Limit(req.PageSize + 1)
+	}); err != nil {
+		return "", err
+	}
+	slice := reflect.Indirect(value)
+	if uint64(slice.Len()) <= req.PageSize {
+		return "", nil
+	}
+	slice.Set(slice.Slice(0, int(req.PageSize)))
+	last := reflect.Indirect(slice.Index(slice.Len() - 1))
+	values := make([]interface{}, len(columns))
+	for i, c := range columns {
+		fv, ok := fieldByIndex(last, c.field.FieldIndex)
+		if !ok {
+			return "", fmt.Errorf("keyset column %s is nil", c.Column)
+		}
+		values[i] = fv.Interface()
+	}
+	return encodePageToken(fingerprint, values)
+}
+
+// selectExpr returns the SQL expression of a selected column (without the alias)
+func selectExpr(v TagData) string {
+	expr := v.MetaString("select", "")
+	if expr == "" {
+		return v.Name
+	}
+	if i := strings.Index(strings.ToUpper(expr), " AS "); i > -1 {
+		return strings.TrimSpace(expr[:i])
+	}
+	return expr
+}
+
+// keysetColumns matches the requested sort columns with the scanned fields (or finds the tagged ones)
+func keysetColumns(columnsResult ColumnsResult, requested []KeysetColumn) ([]keysetColumn, error) {
+	columns := make([]keysetColumn, 0)
+	if len(requested) == 0 {
+		for _, v := range columnsResult.Columns {
+			order, ok := v.MetaStringCheck("keyset")
+			switch strings.ToLower(order) {
+			case "false":
+				continue
+			case "desc":
+				columns = append(columns, keysetColumn{KeysetColumn{Column: selectExpr(v), Desc: true}, v})
+			default:
+				if ok {
+					columns = append(columns, keysetColumn{KeysetColumn{Column: selectExpr(v)}, v})
+				}
+			}
+		}
+		if len(columns) == 0 {
+			return nil, errors.New("no keyset columns (use KeysetRequest.Columns or the 'keyset' subtag)")
+		}
+		return columns, nil
+	}
+	for _, c := range requested {
+		found := false
+		for _, v := range columnsResult.Columns {
+			if v.Name == "-" || v.Name == "" {
+				continue
+			}
+			if c.Column == selectExpr(v) || c.Column == v.Name {
+				columns = append(columns, keysetColumn{c, v})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("keyset column %s is not selected", c.Column)
+		}
+	}
+	return columns, nil
+}
+
+// keysetPredicate returns the seek predicate of the last row values
+func keysetPredicate(columns []keysetColumn, values []interface{}) squirrel.Sqlizer {
+	sameDirection := true
+	for _, c := range columns[1:] {
+		if c.Desc != columns[0].Desc {
+			sameDirection = false
+		}
+	}
+	op := func(c keysetColumn) string {
+		if c.Desc {
+			return " < "
+		}
+		return " > "
+	}
+	if sameDirection {
+		// (a, b) > (?, ?)
+		names := make([]string, len(columns))
+		for i, c := range columns {
+			names[i] = c.Column
+		}
+		if len(columns) == 1 {
+			return squirrel.Expr(names[0]+op(columns[0])+"?", values[0])
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+		return squirrel.Expr("("+strings.Join(names, ", ")+")"+op(columns[0])+"("+placeholders+")", values...)
+	}
+	// (a > ?) OR (a = ? AND b < ?) OR ...
+	or := squirrel.Or{}
+	for i, c := range columns {
+		and := squirrel.And{}
+		for j := 0; j < i; j++ {
+			and = append(and, squirrel.Expr(columns[j].Column+" = ?", values[j]))
+		}
+		and = append(and, squirrel.Expr(c.Column+op(c)+"?", values[i]))
+		or = append(or, and)
+	}
+	return or
+}
+
+// keysetWhere returns the WHERE filters of qfn (SQL and args), so a token can't be used with other filters
+func keysetWhere(qfn func(rq squirrel.SelectBuilder) squirrel.SelectBuilder) (string, error) {
+	if qfn == nil {
+		return "", nil
+	}
+	parts, _ := builder.Get(qfn(squirrel.Select()), "WhereParts")
+	where, _ := parts.([]squirrel.Sqlizer)
+	if len(where) == 0 {
+		return "", nil
+	}
+	sql, args, err := squirrel.And(where).ToSql()
+	if err != nil {
+		return "", err
+	}
+	b := &strings.Builder{}
+	b.WriteString(sql)
+	for _, arg := range args {
+		fmt.Fprintf(b, "\x00%T:%v", arg, arg)
+	}
+	return b.String(), nil
+}
+
+// keysetFingerprint identifies the table, filters (see keysetWhere) and sort columns of a token
+func keysetFingerprint(table, where string, columns []keysetColumn) string {
+	h := sha256.New()
+	h.Write([]byte(table))
+	fmt.Fprintf(h, "\x00%x", sha256.Sum256([]byte(where)))
+	for _, c := range columns {
+		fmt.Fprintf(h, "\x00%s\x00%t", c.Column, c.Desc)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+type pageToken struct {
+	Fingerprint string            `json:"f"`
+	Values      []json.RawMessage `json:"v"`
+}
+
+func encodePageToken(fingerprint string, values []interface{}) (string, error) {
+	tk := pageToken{
+		Fingerprint: fingerprint,
+		Values:      make([]json.RawMessage, len(values)),
+	}
+	for i, v := range values {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode page token: %w", err)
+		}
+		tk.Values[i] = raw
+	}
+	payload, err := json.Marshal(tk)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode page token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signPageToken(payload)), nil
+}
+
+// decodePageToken verifies the token and decodes the values (with the types of the keyset fields)
+func decodePageToken(token, fingerprint string, columns []keysetColumn) ([]interface{}, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidPageToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, signPageToken(payload)) {
+		return nil, ErrInvalidPageToken
+	}
+	tk := pageToken{}
+	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&tk); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	if tk.Fingerprint != fingerprint || len(tk.Values) != len(columns) {
+		return nil, ErrInvalidPageToken
+	}
+	values := make([]interface{}, len(columns))
+	for i, c := range columns {
+		v := reflect.New(c.field.FieldValue.Type())
+		if err := json.Unmarshal(tk.Values[i], v.Interface()); err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		values[i] = v.Elem().Interface()
+		if ts, ok := values[i].(*timestamppb.Timestamp); ok && ts != nil {
+			// bound like resolveValue
+			values[i] = ts.AsTime()
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,189 @@
+package protodb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	"github.com/pedidopago/protodb/protodbpb/testpb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+type keysetOrder struct {
+	ID        int64  `db:"id,table=orders o,select=o.id,keyset=asc"`
+	CreatedAt string `db:"created_at,select=o.created_at"`
+}
+
+func TestSelectKeysetContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT o.id, o.created_at FROM orders o ORDER BY o.id ASC LIMIT 3")).
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}).AddRow(1, "a").AddRow(2, "b").AddRow(3, "c"))
+	items := make([]*keysetOrder, 0)
+	token, err := protodb.SelectKeysetContext(ctx, db, &items, protodb.KeysetRequest{PageSize: 2}, nil)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.NotEmpty(t, token)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT o.id, o.created_at FROM orders o WHERE o.id > ? ORDER BY o.id ASC LIMIT 3")).
+		WithArgs(int64(2)).
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}).AddRow(3, "c"))
+	items = make([]*keysetOrder, 0)
+	token, err = protodb.SelectKeysetContext(ctx, db, &items, protodb.KeysetRequest{PageSize: 2, PageToken: token}, nil)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Empty(t, token)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelectKeysetContextQfnOrderBy(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	// the ORDER BY of qfn is replaced by the keyset order
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT o.id, o.created_at FROM orders o WHERE o.created_at > ? ORDER BY o.id ASC LIMIT 3") + "$").
+		WithArgs("a").
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}).AddRow(1, "b"))
+	items := make([]*keysetOrder, 0)
+	_, err := protodb.SelectKeysetContext(context.Background(), db, &items, protodb.KeysetRequest{PageSize: 2}, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("o.created_at > ?", "a").OrderBy("o.created_at DESC")
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelectKeysetContextColumns(t *testing.T) {
+	db, mock := ptesting.MockDBPostgres(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	req := protodb.KeysetRequest{
+		PageSize: 1,
+		Columns: []protodb.KeysetColumn{
+			{Column: "o.created_at", Desc: true},
+			{Column: "o.id", Desc: true},
+		},
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("ORDER BY o.created_at DESC, o.id DESC LIMIT 2")).
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}).AddRow(9, "z").AddRow(8, "y"))
+	items := make([]keysetOrder, 0)
+	token, err := protodb.SelectKeysetContext(ctx, db, &items, req, nil)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	req.PageToken = token
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (o.created_at, o.id) < ($1, $2) ORDER BY")).
+		WithArgs("z", int64(9)).
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}))
+	_, err = protodb.SelectKeysetContext(ctx, db, &items, req, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// tampered token
+	req.PageToken = "x" + token
+	_, err = protodb.SelectKeysetContext(ctx, db, &items, req, nil)
+	require.ErrorIs(t, err, protodb.ErrInvalidPageToken)
+
+	// token of a different sort order
+	req.PageToken = token
+	req.Columns[1].Desc = false
+	_, err = protodb.SelectKeysetContext(ctx, db, &items, req, nil)
+	require.ErrorIs(t, err, protodb.ErrInvalidPageToken)
+}
+
+func TestSelectKeysetContextMixedOrder(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	req := protodb.KeysetRequest{
+		PageSize: 1,
+		Columns: []protodb.KeysetColumn{
+			{Column: "o.created_at", Desc: true},
+			{Column: "o.id"},
+		},
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("ORDER BY o.created_at DESC, o.id ASC LIMIT 2")).
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}).AddRow(1, "z").AddRow(2, "z"))
+	items := make([]keysetOrder, 0)
+	token, err := protodb.SelectKeysetContext(ctx, db, &items, req, nil)
+	require.NoError(t, err)
+
+	req.PageToken = token
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE ((o.created_at < ?) OR (o.created_at = ? AND o.id > ?)) ORDER BY")).
+		WithArgs("z", "z", int64(1)).
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}))
+	_, err = protodb.SelectKeysetContext(ctx, db, &items, req, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelectKeysetContextWhere(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+	customer := func(id int) func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+			return rq.Where("o.customer_id = ?", id)
+		}
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT o.id, o.created_at FROM orders o WHERE o.customer_id = ? ORDER BY o.id ASC LIMIT 2")).
+		WithArgs(1).
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}).AddRow(1, "a").AddRow(2, "b"))
+	items := make([]*keysetOrder, 0)
+	token, err := protodb.SelectKeysetContext(ctx, db, &items, protodb.KeysetRequest{PageSize: 1}, customer(1))
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	// the token is bound to the filters
+	_, err = protodb.SelectKeysetContext(ctx, db, &items, protodb.KeysetRequest{PageSize: 1, PageToken: token}, customer(2))
+	require.ErrorIs(t, err, protodb.ErrInvalidPageToken)
+	_, err = protodb.SelectKeysetContext(ctx, db, &items, protodb.KeysetRequest{PageSize: 1, PageToken: token}, nil)
+	require.ErrorIs(t, err, protodb.ErrInvalidPageToken)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT o.id, o.created_at FROM orders o WHERE o.customer_id = ? AND o.id > ? ORDER BY o.id ASC LIMIT 2")).
+		WithArgs(1, int64(1)).
+		WillReturnRows(mock.NewRows([]string{"id", "created_at"}).AddRow(2, "b"))
+	_, err = protodb.SelectKeysetContext(ctx, db, &items, protodb.KeysetRequest{PageSize: 1, PageToken: token}, customer(1))
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelectKeysetContextTimestamp(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	req := protodb.KeysetRequest{
+		PageSize: 1,
+		Columns:  []protodb.KeysetColumn{{Column: "created_at"}, {Column: "id"}},
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("ORDER BY created_at ASC, id ASC LIMIT 2")).
+		WillReturnRows(mock.NewRows([]string{"id", "status", "total", "customer_name", "created_at"}).
+			AddRow("o1", 1, 10, "a", ts).AddRow("o2", 1, 20, "b", ts))
+	items := make([]*testpb.Order, 0)
+	token, err := protodb.SelectKeysetContext(ctx, db, &items, req, nil)
+	require.NoError(t, err)
+
+	// the timestamp of the token is bound as a time.Time
+	req.PageToken = token
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (created_at, id) > (?, ?)")).
+		WithArgs(ts, "o1").
+		WillReturnRows(mock.NewRows([]string{"id", "status", "total", "customer_name", "created_at"}))
+	_, err = protodb.SelectKeysetContext(ctx, db, &items, req, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
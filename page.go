@@ -0,0 +1,107 @@
+package protodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lann/builder"
+)
+
+// CountContext counts the rows that SelectContext (or GetContext) would return for the model and qfn.
+// The model can be a struct, a pointer to a struct or a pointer to a slice (the table and joins are
+// extracted with SelectColumnScan). ORDER BY, LIMIT and OFFSET added by qfn are removed; queries with
+// GROUP BY, HAVING or DISTINCT are counted as a subquery.
+func CountContext(ctx context.Context, dbtx sqlx.QueryerContext, model interface{}, qfn func(rq squirrel.SelectBuilder) squirrel.SelectBuilder) (int64, error) {
+	dbtx = Queryer(ctx, dbtx)
+	columnsResult := modelColumnScan(model)
+	if columnsResult.Err != nil {
+		return 0, columnsResult.Err
+	}
+	rq, err := selectBuilder(ctx, dbtx, columnsResult)
+	if err != nil {
+		return 0, err
+	}
+	if qfn != nil {
+		rq = qfn(rq)
+	}
+	rq = columnsResult.pruneJoins(ctx, rq)
+	rq = countBuilder(DialectOf(ctx, dbtx), rq)
+	q, args, err := rq.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	var total int64
+	if err := sqlx.GetContext(ctx, dbtx, &total, q, args...); err != nil {
+		return 0, ClassifyError(err)
+	}
+	return total, nil
+}
+
+// countBuilder transforms a SELECT into a SELECT COUNT(*)
+func countBuilder(dialect Dialect, rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+	rq = builder.Delete(rq, "OrderByParts").(squirrel.SelectBuilder)
+	rq = rq.RemoveLimit().RemoveOffset()
+	if builderHasParts(rq, "GroupBys") || builderHasParts(rq, "HavingParts") || builderHasParts(rq, "Options") {
+		return dialect.Builder().Select("COUNT(*)").FromSelect(rq, "protodb_count")
+	}
+	rq = builder.Delete(rq, "Columns").(squirrel.SelectBuilder)
+	return rq.Columns("COUNT(*)")
+}
+
+// PageRequest is the page requested by SelectPageContext
+type PageRequest struct {
+	Size   uint64 // max number of rows of the page (required)
+	Offset uint64 // number of rows to skip; if 0, Number is used
+	Number uint64 // page number, starting at 1 (used only if Offset is 0)
+}
+
+// offset returns the number of rows to skip
+func (p PageRequest) offset() uint64 {
+	if p.Offset > 0 || p.Number < 2 {
+		return p.Offset
+	}
+	return (p.Number - 1) * p.Size
+}
+
+// Page is the pagination info returned by SelectPageContext
+type Page struct {
+	Total   int64  // total number of rows (without LIMIT/OFFSET)
+	Size    uint64 // page size
+	Offset  uint64 // number of skipped rows
+	HasNext bool   // true if there are rows after this page
+}
+
+// SelectPageContext executes a CountContext and a SelectContext limited to the requested page.
+// dest must be a pointer to a slice. LIMIT and OFFSET are set by page (after qfn is applied).
+// Example:
+//      items := make([]*Order, 0)
+//      page, err := protodb.SelectPageContext(ctx, db, &items, protodb.PageRequest{Size: 20, Number: 2}, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+//          return rq.Where("store_id=?", storeID).OrderBy("id DESC")
+//      })
+func SelectPageContext(ctx context.Context, dbtx sqlx.QueryerContext, dest interface{}, page PageRequest, qfn func(rq squirrel.SelectBuilder) squirrel.SelectBuilder) (*Page, error) {
+	if page.Size < 1 {
+		return nil, errors.New("page size must be greater than 0")
+	}
+	total, err := CountContext(ctx, dbtx, dest, qfn)
+	if err != nil {
+		return nil, err
+	}
+	result := &Page{
+		Total:  total,
+		Size:   page.Size,
+		Offset: page.offset(),
+	}
+	if err := SelectContext(ctx, dbtx, dest, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		if qfn != nil {
+			rq = qfn(rq)
+		}
+		return rq.Limit(page.Size).Offset(result.Offset)
+	}); err != nil {
+		return nil, err
+	}
+	result.HasNext = int64(result.Offset+page.Size) < total
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package protodb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+type pageItem struct {
+	ID    int    `db:"id,table=accounts act,select=act.id"`
+	Name  string `db:"name,select=act.full_name AS name"`
+	Score int    `db:"score,select=ascore.score,join=LEFT JOIN accounts_score ascore ON ascore.account_id=act.id"`
+}
+
+func TestCountContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM accounts act LEFT JOIN accounts_score ascore ON ascore.account_id=act.id WHERE full_name LIKE ?")).
+		WithArgs("A%").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(12))
+	total, err := protodb.CountContext(context.Background(), db, &[]*pageItem{}, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("full_name LIKE ?", "A%").OrderBy("id ASC").Limit(10).Offset(20)
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(12), total)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM (SELECT act.id, act.full_name AS name, ascore.score FROM accounts act LEFT JOIN accounts_score ascore ON ascore.account_id=act.id GROUP BY act.id) AS protodb_count")).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
+	total, err = protodb.CountContext(context.Background(), db, pageItem{}, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.GroupBy("act.id").OrderBy("id ASC")
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), total)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelectPageContext(t *testing.T) {
+	db, mock := ptesting.MockDBPostgres(t)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM accounts act")).
+		WithArgs("A%").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE full_name LIKE $1 ORDER BY id ASC LIMIT 2 OFFSET 2")).
+		WithArgs("A%").WillReturnRows(mock.NewRows([]string{"id", "name", "score"}).AddRow(3, "Alice", 1).AddRow(4, "Anne", 2))
+
+	items := make([]*pageItem, 0)
+	page, err := protodb.SelectPageContext(context.Background(), db, &items, protodb.PageRequest{Size: 2, Number: 2}, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("full_name LIKE ?", "A%").OrderBy("id ASC")
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, items, 2)
+	require.Equal(t, int64(5), page.Total)
+	require.Equal(t, uint64(2), page.Offset)
+	require.True(t, page.HasNext)
+
+	_, err = protodb.SelectPageContext(context.Background(), db, &items, protodb.PageRequest{}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,513 @@
+package protodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pedidopago/protodb/protodbpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ProtoColumnScan maps the columns of a protobuf message with protoreflect, using the protodb options
+// (protodbpb/options.proto) instead of struct tags:
+//      import "protodbpb/options.proto";
+//
+//      message Order {
+//        option (protodb.table) = { name: "orders o" };
+//
+//        string id = 1 [(protodb.column) = { select: "o.id", subtags: ["key"] }];
+//        string customer_name = 2 [(protodb.column) = {
+//          select: "c.name AS customer_name",
+//          join: "LEFT JOIN customers c ON c.id=o.customer_id",
+//          select_only: true
+//        }];
+//        google.protobuf.Timestamp created_at = 3 [(protodb.column) = { subtags: ["autocreate"] }];
+//        repeated Item items = 4; // not a column
+//      }
+// The column name is the field name (unless the "name" option is set). Scalar, enum and
+// google.protobuf.Timestamp fields are columns; other message, repeated, map and oneof fields are
+// ignored unless they have the protodb.column option.
+//
+// SelectColumnScan, InsertColumnScan, UpdateColumnScan and DeleteColumnScan (and the CRUD functions)
+// use ProtoColumnScan for messages with the protodb.table option. The selected rows of these messages
+// are scanned by column order (not by the db struct tags).
+// The columns of a Model (generated by protoc-gen-protodb) are not extracted with protoreflect.
+func ProtoColumnScan(m proto.Message) ColumnsResult {
+	if r, ok := protoColumnScan(m, true); ok {
+		return r
+	}
+	return ColumnsResult{Err: fmt.Errorf("%T has no protodb.table option", m)}
+}
+
+// Model is implemented by the messages generated by protoc-gen-protodb (cmd/protoc-gen-protodb).
+// The column scans (and the CRUD functions) use the generated columns of a Model instead of extracting
+// them with protoreflect, the selected rows are scanned by ProtodbScan and the inserted and updated
+// values are built by ProtodbValue.
+// Example:
+//      protoc --go_out=. --protodb_out=. order.proto
+type Model interface {
+	// ProtodbColumns returns the columns (and the field values) of the message.
+	// The select_only columns are omitted if selecting is false.
+	ProtodbColumns(selecting bool) []TagData
+	// ProtodbScan returns the scan destinations of the selected columns (in the same order).
+	ProtodbScan(columns []string) ([]interface{}, error)
+	// ProtodbValue returns the value of the column to be inserted or updated
+	// (false if the message has no such column).
+	ProtodbValue(column string) (interface{}, bool)
+}
+
+// protoColumnScan executes a ProtoColumnScan if v is a proto.Message with the protodb.table option.
+// The select_only columns are omitted if selecting is false.
+func protoColumnScan(v interface{}, selecting bool) (ColumnsResult, bool) {
+	vval, ok := v.(reflect.Value)
+	if !ok {
+		vval = reflect.ValueOf(v)
+	}
+	if !vval.IsValid() {
+		return ColumnsResult{}, false
+	}
+	if vval.Kind() == reflect.Struct {
+		if !vval.CanAddr() {
+			cp := reflect.New(vval.Type())
+			cp.Elem().Set(vval)
+			vval = cp
+		} else {
+			vval = vval.Addr()
+		}
+	}
+	for vval.Kind() == reflect.Ptr && !vval.IsNil() && vval.Elem().Kind() == reflect.Ptr {
+		vval = vval.Elem()
+	}
+	if vval.Kind() != reflect.Ptr || vval.IsNil() || vval.Elem().Kind() != reflect.Struct {
+		return ColumnsResult{}, false
+	}
+	if model, ok := vval.Interface().(Model); ok {
+		columns := model.ProtodbColumns(selecting)
+		for i := range columns {
+			columns[i].model = model
+			if columns[i].FieldIndex == nil {
+				columns[i].FieldIndex = modelFieldIndex(vval.Elem().Type(), columns[i].FieldName)
+			}
+		}
+		return ColumnsResult{
+			Columns:   columns,
+			typ:       vval.Elem().Type(),
+			protoScan: true,
+			model:     model,
+		}, true
+	}
+	m, ok := vval.Interface().(proto.Message)
+	if !ok {
+		return ColumnsResult{}, false
+	}
+	fields, ok := cachedProtoMeta(vval.Elem().Type(), m.ProtoReflect().Descriptor())
+	if !ok {
+		return ColumnsResult{}, false
+	}
+	columns := resolveFields(vval.Elem(), fields)
+	if !selecting {
+		x := columns[:0]
+		for _, c := range columns {
+			if !c.MetaFlag("selectonly") {
+				x = append(x, c)
+			}
+		}
+		columns = x
+	}
+	return ColumnsResult{
+		Columns:   columns,
+		typ:       vval.Elem().Type(),
+		protoScan: true,
+	}, true
+}
+
+// protoMeta is the cached protoTypeMeta of a message type
+type protoMeta struct {
+	fields []fieldMeta
+	ok     bool
+}
+
+func cachedProtoMeta(t reflect.Type, desc protoreflect.MessageDescriptor) ([]fieldMeta, bool) {
+	key := metaCacheKey{
+		typ:  t,
+		tags: "\x00protoreflect",
+	}
+	pm, ok := metaCache.Load(key)
+	if !ok {
+		fields, ok := protoTypeMeta(t, desc)
+		pm, _ = metaCache.LoadOrStore(key, protoMeta{fields: fields, ok: ok})
+	}
+	return pm.(protoMeta).fields, pm.(protoMeta).ok
+}
+
+// ProtoColumn is a column of a message mapped from its protodb options (see ProtoColumns)
+type ProtoColumn struct {
+	Name  string
+	Meta  map[string]string
+	Field protoreflect.FieldDescriptor
+}
+
+// ProtoColumns maps the protodb options of the message desc to its columns (false if desc has no
+// protodb.table option). It is the mapping of ProtoColumnScan, shared with protoc-gen-protodb.
+// The "table" and "select_table" meta are set on the first column that is not select_only.
+func ProtoColumns(desc protoreflect.MessageDescriptor) ([]ProtoColumn, bool) {
+	table, _ := proto.GetExtension(desc.Options(), protodbpb.E_Table).(*protodbpb.TableOptions)
+	if table.GetName() == "" && table.GetSelectTable() == "" {
+		return nil, false
+	}
+	x := make([]ProtoColumn, 0, desc.Fields().Len())
+	for i := 0; i < desc.Fields().Len(); i++ {
+		fd := desc.Fields().Get(i)
+		column, _ := proto.GetExtension(fd.Options(), protodbpb.E_Column).(*protodbpb.ColumnOptions)
+		if column.GetSkip() || fd.ContainingOneof() != nil {
+			continue
+		}
+		explicit := proto.HasExtension(fd.Options(), protodbpb.E_Column)
+		isTimestamp := fd.Message() != nil && fd.Message().FullName() == "google.protobuf.Timestamp"
+		if !explicit && (fd.IsList() || fd.IsMap() || (fd.Message() != nil && !isTimestamp)) {
+			continue
+		}
+		item := ProtoColumn{
+			Name:  column.GetName(),
+			Meta:  make(map[string]string),
+			Field: fd,
+		}
+		if item.Name == "" {
+			item.Name = string(fd.Name())
+		}
+		for _, vf := range column.GetSubtags() {
+			if keyval := strings.SplitN(vf, "=", 2); len(keyval) == 2 {
+				item.Meta[strings.TrimSpace(keyval[0])] = keyval[1]
+			} else if strings.TrimSpace(vf) != "" {
+				item.Meta[strings.TrimSpace(vf)] = ""
+			}
+		}
+		if v := column.GetSelect(); v != "" {
+			item.Meta["select"] = v
+		}
+		if v := column.GetJoin(); v != "" {
+			item.Meta["join"] = v
+		}
+		if column.GetSelectOnly() {
+			item.Meta["selectonly"] = "true"
+		}
+		x = append(x, item)
+	}
+	// the table is set on the first column that is not select_only (which is also inserted and updated)
+	for i := range x {
+		if v := (TagData{Meta: x[i].Meta}); !v.MetaFlag("selectonly") || i == len(x)-1 {
+			if table.GetName() != "" {
+				x[i].Meta["table"] = table.GetName()
+			}
+			if table.GetSelectTable() != "" {
+				x[i].Meta["select_table"] = table.GetSelectTable()
+			}
+			break
+		}
+	}
+	return x, true
+}
+
+// protoTypeMeta maps the ProtoColumns of the message desc to the fields of t (the generated struct type)
+func protoTypeMeta(t reflect.Type, desc protoreflect.MessageDescriptor) ([]fieldMeta, bool) {
+	columns, ok := ProtoColumns(desc)
+	if !ok {
+		return nil, false
+	}
+	goFields := protoGoFields(t)
+	x := make([]fieldMeta, 0, len(columns))
+	for _, c := range columns {
+		sf, ok := goFields[string(c.Field.Name())]
+		if !ok {
+			continue
+		}
+		x = append(x, fieldMeta{
+			index:     sf.Index,
+			name:      c.Name,
+			meta:      c.Meta,
+			fieldName: sf.Name,
+		})
+	}
+	return x, true
+}
+
+// protoGoFields returns the fields of the generated struct type t by proto field name
+func protoGoFields(t reflect.Type) map[string]reflect.StructField {
+	// generated fields: protobuf:"bytes,1,opt,name=store_id,json=storeId,proto3"
+	goFields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		for _, opt := range strings.Split(sf.Tag.Get("protobuf"), ",") {
+			if strings.HasPrefix(opt, "name=") {
+				goFields[strings.TrimPrefix(opt, "name=")] = sf
+			}
+		}
+	}
+	return goFields
+}
+
+// modelFieldIndex returns the (cached) index path of the Go field name of the generated struct type t
+func modelFieldIndex(t reflect.Type, name string) []int {
+	key := metaCacheKey{
+		typ:  t,
+		tags: "\x00model\x00" + name,
+	}
+	index, ok := metaCache.Load(key)
+	if !ok {
+		var x []int
+		if sf, ok := t.FieldByName(name); ok {
+			x = sf.Index
+		}
+		index, _ = metaCache.LoadOrStore(key, x)
+	}
+	return index.([]int)
+}
+
+// getProto executes the query and scans the first row into the columns (by position)
+func getProto(ctx context.Context, dbtx sqlx.QueryerContext, columnsResult ColumnsResult, q string, args ...interface{}) error {
+	rows, err := dbtx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	return scanProtoRow(ctx, rows, columnsResult)
+}
+
+// selectProto executes the query and appends each row to the slice dest (by position)
+func selectProto(ctx context.Context, dbtx sqlx.QueryerContext, dest reflect.Value, q string, args ...interface{}) error {
+	rows, err := dbtx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	slice := reflect.Indirect(dest)
+	isPtr := slice.Type().Elem().Kind() == reflect.Ptr
+	base := slice.Type().Elem()
+	if isPtr {
+		base = base.Elem()
+	}
+	for rows.Next() {
+		vp := reflect.New(base)
+		columnsResult := SelectColumnScan(vp)
+		if columnsResult.Err != nil {
+			return columnsResult.Err
+		}
+		if err := scanProtoRow(ctx, rows, columnsResult); err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, vp))
+		} else {
+			slice.Set(reflect.Append(slice, vp.Elem()))
+		}
+	}
+	return rows.Err()
+}
+
+// scanProtoRow scans the current row into the selected columns of columnsResult (in the same order)
+func scanProtoRow(ctx context.Context, rows *sqlx.Rows, columnsResult ColumnsResult) error {
+	columns := columnsResult.selectedColumns(ctx)
+	if columnsResult.model != nil {
+		names := make([]string, len(columns))
+		for i, v := range columns {
+			names[i] = v.Name
+		}
+		dests, err := columnsResult.model.ProtodbScan(names)
+		if err != nil {
+			return err
+		}
+		return rows.Scan(dests...)
+	}
+	dests := make([]interface{}, len(columns))
+	for i, v := range columns {
+		dests[i] = &protoField{name: v.Name, v: v.FieldValue}
+	}
+	return rows.Scan(dests...)
+}
+
+// FieldScanner returns the sql.Scanner of a field of a protobuf message (dst is a pointer to the field).
+// NULL sets the zero value, and a time column can be scanned into a *timestamppb.Timestamp field.
+// It is used by the code generated by protoc-gen-protodb.
+func FieldScanner(column string, dst interface{}) sql.Scanner {
+	return &protoField{name: column, dst: dst}
+}
+
+// protoField is the sql.Scanner of a field of a protobuf message
+type protoField struct {
+	name string
+	v    reflect.Value
+	dst  interface{} // pointer to the field (FieldScanner)
+}
+
+func (f *protoField) Scan(src interface{}) error {
+	if f.dst != nil {
+		if ok, err := f.scanDirect(src); ok {
+			return err
+		}
+		f.v = reflect.ValueOf(f.dst).Elem()
+		f.dst = nil
+	}
+	if src == nil {
+		f.v.Set(reflect.Zero(f.v.Type()))
+		return nil
+	}
+	if sc, ok := f.v.Addr().Interface().(sql.Scanner); ok {
+		return sc.Scan(src)
+	}
+	if _, ok := f.v.Interface().(*timestamppb.Timestamp); ok {
+		t, err := scanTime(src)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", f.name, err)
+		}
+		f.v.Set(reflect.ValueOf(timestamppb.New(t)))
+		return nil
+	}
+	if f.v.Kind() == reflect.Ptr {
+		// pointer fields (e.g. *int64, *time.Time) are allocated and the value is scanned into the element
+		elem := reflect.New(f.v.Type().Elem())
+		if err := (&protoField{name: f.name, v: elem.Elem()}).Scan(src); err != nil {
+			return err
+		}
+		f.v.Set(elem)
+		return nil
+	}
+	if b, ok := src.([]byte); ok {
+		if f.v.Kind() == reflect.Slice && f.v.Type().Elem().Kind() == reflect.Uint8 {
+			f.v.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+		src = string(b)
+	}
+	sv := reflect.ValueOf(src)
+	switch f.v.Kind() {
+	case reflect.String:
+		f.v.SetString(fmt.Sprint(src))
+		return nil
+	case reflect.Bool:
+		switch x := src.(type) {
+		case bool:
+			f.v.SetBool(x)
+			return nil
+		case string:
+			b, err := strconv.ParseBool(x)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", f.name, err)
+			}
+			f.v.SetBool(b)
+			return nil
+		}
+		if sv.Kind() >= reflect.Int && sv.Kind() <= reflect.Int64 {
+			f.v.SetBool(sv.Int() != 0)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s, ok := src.(string); ok {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", f.name, err)
+			}
+			f.v.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s, ok := src.(string); ok {
+			n, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", f.name, err)
+			}
+			f.v.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if s, ok := src.(string); ok {
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", f.name, err)
+			}
+			f.v.SetFloat(n)
+			return nil
+		}
+	}
+	if sv.Type().ConvertibleTo(f.v.Type()) {
+		f.v.Set(sv.Convert(f.v.Type()))
+		return nil
+	}
+	return fmt.Errorf("column %s: cannot scan %T into %v", f.name, src, f.v.Type())
+}
+
+// scanDirect scans the common driver values without reflection (false if dst is not supported)
+func (f *protoField) scanDirect(src interface{}) (bool, error) {
+	switch dst := f.dst.(type) {
+	case *string:
+		switch x := src.(type) {
+		case string:
+			*dst = x
+			return true, nil
+		case []byte:
+			*dst = string(x)
+			return true, nil
+		}
+	case *int64:
+		if x, ok := src.(int64); ok {
+			*dst = x
+			return true, nil
+		}
+	case *int32:
+		if x, ok := src.(int64); ok {
+			*dst = int32(x)
+			return true, nil
+		}
+	case *bool:
+		if x, ok := src.(bool); ok {
+			*dst = x
+			return true, nil
+		}
+	case *float64:
+		if x, ok := src.(float64); ok {
+			*dst = x
+			return true, nil
+		}
+	case **timestamppb.Timestamp:
+		if src == nil {
+			*dst = nil
+			return true, nil
+		}
+		t, err := scanTime(src)
+		if err != nil {
+			return true, fmt.Errorf("column %s: %w", f.name, err)
+		}
+		*dst = timestamppb.New(t)
+		return true, nil
+	}
+	return false, nil
+}
+
+// scanTime converts a time column (time.Time or a string if the driver does not parse times)
+func scanTime(src interface{}) (time.Time, error) {
+	switch x := src.(type) {
+	case time.Time:
+		return x, nil
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+			if t, err := time.Parse(layout, x); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, errors.New("cannot scan time from " + fmt.Sprint(src))
+}
@@ -0,0 +1,229 @@
+package protodb_test
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	"github.com/pedidopago/protodb/protodbpb/testpb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestProtoColumnScan(t *testing.T) {
+	r := protodb.ProtoColumnScan(&testpb.Order{Id: "o1", TotalCents: 100})
+	require.NoError(t, r.Err)
+	require.Equal(t, "orders", r.GetTableNameMeta(context.Background()))
+	names := make([]string, 0)
+	for _, c := range r.Columns {
+		names = append(names, c.Name)
+	}
+	require.Equal(t, []string{"id", "status", "total", "customer_name", "created_at"}, names)
+	require.True(t, r.Columns[0].IsKey())
+	require.Equal(t, "c.name AS customer_name", r.Columns[3].MetaString("select", ""))
+
+	// the select_only columns are not inserted
+	r = protodb.InsertColumnScan(&testpb.Order{})
+	require.NoError(t, r.Err)
+	require.Len(t, r.Columns, 4)
+
+	// messages without the protodb.table option are not supported
+	require.Error(t, protodb.ProtoColumnScan(&testpb.Item{}).Err)
+}
+
+func TestProtoGetSelect(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+	created := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	q := "SELECT id, status, total, c.name AS customer_name, created_at FROM orders LEFT JOIN customers c ON c.id=orders.customer_id WHERE id = ?"
+	mock.ExpectQuery("^" + regexp.QuoteMeta(q) + "$").WithArgs("o1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "total", "customer_name", "created_at"}).
+			AddRow("o1", 1, []byte("100"), "Ana", created))
+	order := &testpb.Order{}
+	require.NoError(t, protodb.GetContext(ctx, db, order, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("id = ?", "o1")
+	}))
+	require.Equal(t, "o1", order.Id)
+	require.Equal(t, testpb.OrderStatus_ORDER_STATUS_PAID, order.Status)
+	require.Equal(t, int64(100), order.TotalCents)
+	require.Equal(t, "Ana", order.CustomerName)
+	require.True(t, created.Equal(order.CreatedAt.AsTime()))
+
+	mock.ExpectQuery("^" + regexp.QuoteMeta(q) + "$").WithArgs("o2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "total", "customer_name", "created_at"}))
+	require.ErrorIs(t, protodb.GetContext(ctx, db, &testpb.Order{}, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("id = ?", "o2")
+	}), sql.ErrNoRows)
+
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT id, status, total, c.name AS customer_name, created_at FROM orders LEFT JOIN customers c ON c.id=orders.customer_id") + "$").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "total", "customer_name", "created_at"}).
+			AddRow("o1", 1, 100, "Ana", created).
+			AddRow("o2", 0, 50, nil, nil))
+	orders := make([]*testpb.Order, 0)
+	require.NoError(t, protodb.SelectContext(ctx, db, &orders, nil))
+	require.Len(t, orders, 2)
+	require.Equal(t, "o2", orders[1].Id)
+	require.Equal(t, "", orders[1].CustomerName)
+	require.Nil(t, orders[1].CreatedAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProtoInsertUpdate(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	created := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	ctx := protodb.WithClock(context.Background(), func() time.Time { return created })
+
+	mock.ExpectExec("^"+regexp.QuoteMeta("INSERT INTO orders (id,status,total,created_at) VALUES (?,?,?,?)")+"$").
+		WithArgs("o1", testpb.OrderStatus_ORDER_STATUS_PAID, int64(100), created).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	order := &testpb.Order{Id: "o1", Status: testpb.OrderStatus_ORDER_STATUS_PAID, TotalCents: 100, CustomerName: "Ana", Note: "x"}
+	_, err := protodb.InsertContext(ctx, db, order, nil)
+	require.NoError(t, err)
+	require.True(t, created.Equal(order.CreatedAt.AsTime()))
+
+	mock.ExpectExec("^"+regexp.QuoteMeta("UPDATE orders SET status = ?, total = ? WHERE id = ?")+"$").
+		WithArgs(testpb.OrderStatus_ORDER_STATUS_UNSPECIFIED, int64(90), "o1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	order.Status = testpb.OrderStatus_ORDER_STATUS_UNSPECIFIED
+	order.TotalCents = 90
+	order.CreatedAt = timestamppb.New(created)
+	_, err = protodb.UpdateContext(ctx, db, order, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", order.Id)
+	}, "id", "created_at")
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProtoModel(t *testing.T) {
+	// the generated columns have the same field indexes as the struct fields
+	customerType := reflect.TypeOf(testpb.Customer{})
+	r := protodb.SelectColumnScan(&testpb.Customer{})
+	require.NoError(t, r.Err)
+	require.Len(t, r.Columns, len(testpb.CustomerColumns))
+	for i, c := range r.Columns {
+		require.Equal(t, testpb.CustomerColumns[i], c.Name)
+		sf, ok := customerType.FieldByName(c.FieldName)
+		require.True(t, ok)
+		require.Equal(t, sf.Index, c.FieldIndex)
+	}
+	require.Len(t, protodb.InsertColumnScan(&testpb.Customer{}).Columns, len(testpb.CustomerColumns)-1)
+
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+	created := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	q := "SELECT cu.id, cu.name, ci.name AS city, cu.tier, cu.active, cu.score, cu.version, cu.created_at FROM customers cu LEFT JOIN cities ci ON ci.id=cu.city_id"
+	mock.ExpectQuery("^" + regexp.QuoteMeta(q+" WHERE cu.id = ?") + "$").WithArgs("c1").
+		WillReturnRows(sqlmock.NewRows(testpb.CustomerColumns).
+			AddRow("c1", []byte("Ana"), nil, 1, true, []byte("4.5"), 2, created))
+	customer := &testpb.Customer{}
+	require.NoError(t, protodb.GetContext(ctx, db, customer, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("cu.id = ?", "c1")
+	}))
+	require.Equal(t, "Ana", customer.Name)
+	require.Equal(t, "", customer.City)
+	require.Equal(t, testpb.CustomerTier_CUSTOMER_TIER_GOLD, customer.Tier)
+	require.True(t, customer.Active)
+	require.Equal(t, 4.5, customer.Score)
+	require.Equal(t, int64(2), customer.Version)
+	require.True(t, created.Equal(customer.CreatedAt.AsTime()))
+
+	// the read mask is applied to the generated columns
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT cu.id, cu.name FROM customers cu") + "$").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("c1", "Ana").AddRow("c2", "Bia"))
+	customers := make([]*testpb.Customer, 0)
+	mctx := protodb.WithReadMask(ctx, &fieldmaskpb.FieldMask{Paths: []string{"name"}})
+	require.NoError(t, protodb.SelectContext(mctx, db, &customers, nil))
+	require.Len(t, customers, 2)
+	require.Equal(t, "Bia", customers[1].Name)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProtoModelUpdate(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	mock.ExpectExec("^"+regexp.QuoteMeta("UPDATE customers cu SET name = ?, tier = ?, active = ?, score = ?, version = version + 1 WHERE cu.id = ? AND version = ?")+"$").
+		WithArgs("Ana", testpb.CustomerTier_CUSTOMER_TIER_GOLD, true, 4.5, "c1", int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	customer := &testpb.Customer{Id: "c1", Name: "Ana", Tier: testpb.CustomerTier_CUSTOMER_TIER_GOLD, Active: true, Score: 4.5, Version: 2}
+	_, err := protodb.UpdateContext(context.Background(), db, customer, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("cu.id = ?", customer.Id)
+	}, "id", "created_at")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), customer.Version)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProtoModelInsert(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	created := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	// the values are built by the generated ProtodbValue (the timestamp is inserted as a time.Time)
+	customer := &testpb.Customer{Id: "c1", Name: "Ana", Tier: testpb.CustomerTier_CUSTOMER_TIER_GOLD, CreatedAt: timestamppb.New(created)}
+	v, ok := customer.ProtodbValue("created_at")
+	require.True(t, ok)
+	require.Equal(t, created, v)
+	_, ok = customer.ProtodbValue("city_id")
+	require.False(t, ok)
+
+	mock.ExpectExec("^"+regexp.QuoteMeta("INSERT INTO customers cu (id,name,tier,active,score,version,created_at) VALUES (?,?,?,?,?,?,?)")+"$").
+		WithArgs("c1", "Ana", testpb.CustomerTier_CUSTOMER_TIER_GOLD, false, 0.0, int64(0), created).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.InsertContext(context.Background(), db, customer, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProtoSelectOnlyFirstField(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT c.name AS customer_name, id, total FROM orders LEFT JOIN customers c ON c.id=orders.customer_id") + "$").
+		WillReturnRows(sqlmock.NewRows([]string{"customer_name", "id", "total"}).AddRow("Ana", "o1", 100))
+	views := make([]*testpb.OrderView, 0)
+	require.NoError(t, protodb.SelectContext(ctx, db, &views, nil))
+	require.Len(t, views, 1)
+	require.Equal(t, "Ana", views[0].CustomerName)
+
+	// the table is found without the select_only column
+	mock.ExpectExec("^"+regexp.QuoteMeta("INSERT INTO orders (id,total) VALUES (?,?)")+"$").
+		WithArgs("o2", int64(50)).WillReturnResult(sqlmock.NewResult(0, 1))
+	view := &testpb.OrderView{Id: "o2", TotalCents: 50, CustomerName: "Bia"}
+	_, err := protodb.InsertContext(ctx, db, view, nil)
+	require.NoError(t, err)
+
+	mock.ExpectExec("^"+regexp.QuoteMeta("UPDATE orders SET total = ? WHERE id = ?")+"$").
+		WithArgs(int64(60), "o2").WillReturnResult(sqlmock.NewResult(0, 1))
+	view.TotalCents = 60
+	_, err = protodb.UpdateContext(ctx, db, view, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", view.Id)
+	}, "id")
+	require.NoError(t, err)
+
+	mock.ExpectExec("^" + regexp.QuoteMeta("DELETE FROM orders WHERE id = ?") + "$").
+		WithArgs("o2").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = protodb.DeleteContext(ctx, db, view, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
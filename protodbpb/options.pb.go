@@ -0,0 +1,331 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.15.8
+// source: protodbpb/options.proto
+
+package protodbpb
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	descriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+// TableOptions maps a message to a table. Messages with this option are scanned
+// with protoreflect (ProtoColumnScan) instead of the struct tags.
+type TableOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The table name (and alias) used by select, insert, update and delete (e.g. "orders o").
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// The table used only by select (optional).
+	SelectTable string `protobuf:"bytes,2,opt,name=select_table,json=selectTable,proto3" json:"select_table,omitempty"`
+}
+
+func (x *TableOptions) Reset() {
+	*x = TableOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protodbpb_options_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TableOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TableOptions) ProtoMessage() {}
+
+func (x *TableOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_protodbpb_options_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TableOptions.ProtoReflect.Descriptor instead.
+func (*TableOptions) Descriptor() ([]byte, []int) {
+	return file_protodbpb_options_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TableOptions) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TableOptions) GetSelectTable() string {
+	if x != nil {
+		return x.SelectTable
+	}
+	return ""
+}
+
+// ColumnOptions maps a field to a column.
+type ColumnOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The column name (default: the field name).
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// The select expression (e.g. "o.id" or "c.name AS customer_name").
+	Select string `protobuf:"bytes,2,opt,name=select,proto3" json:"select,omitempty"`
+	// The join used to select the column (e.g. "LEFT JOIN customers c ON c.id=o.customer_id").
+	Join string `protobuf:"bytes,3,opt,name=join,proto3" json:"join,omitempty"`
+	// The field is not a column.
+	Skip bool `protobuf:"varint,4,opt,name=skip,proto3" json:"skip,omitempty"`
+	// Other subtags, like in the struct tags (e.g. "key", "autoinc", "skipzero=true", "onconflict=keep").
+	Subtags []string `protobuf:"bytes,5,rep,name=subtags,proto3" json:"subtags,omitempty"`
+	// The column is only selected (e.g. a joined column): insert, update and delete ignore it.
+	SelectOnly bool `protobuf:"varint,6,opt,name=select_only,json=selectOnly,proto3" json:"select_only,omitempty"`
+}
+
+func (x *ColumnOptions) Reset() {
+	*x = ColumnOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protodbpb_options_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ColumnOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColumnOptions) ProtoMessage() {}
+
+func (x *ColumnOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_protodbpb_options_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColumnOptions.ProtoReflect.Descriptor instead.
+func (*ColumnOptions) Descriptor() ([]byte, []int) {
+	return file_protodbpb_options_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ColumnOptions) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ColumnOptions) GetSelect() string {
+	if x != nil {
+		return x.Select
+	}
+	return ""
+}
+
+func (x *ColumnOptions) GetJoin() string {
+	if x != nil {
+		return x.Join
+	}
+	return ""
+}
+
+func (x *ColumnOptions) GetSkip() bool {
+	if x != nil {
+		return x.Skip
+	}
+	return false
+}
+
+func (x *ColumnOptions) GetSubtags() []string {
+	if x != nil {
+		return x.Subtags
+	}
+	return nil
+}
+
+func (x *ColumnOptions) GetSelectOnly() bool {
+	if x != nil {
+		return x.SelectOnly
+	}
+	return false
+}
+
+var file_protodbpb_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptor.MessageOptions)(nil),
+		ExtensionType: (*TableOptions)(nil),
+		Field:         50601,
+		Name:          "protodb.table",
+		Tag:           "bytes,50601,opt,name=table",
+		Filename:      "protodbpb/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptor.FieldOptions)(nil),
+		ExtensionType: (*ColumnOptions)(nil),
+		Field:         50601,
+		Name:          "protodb.column",
+		Tag:           "bytes,50601,opt,name=column",
+		Filename:      "protodbpb/options.proto",
+	},
+}
+
+// Extension fields to descriptor.MessageOptions.
+var (
+	// optional protodb.TableOptions table = 50601;
+	E_Table = &file_protodbpb_options_proto_extTypes[0]
+)
+
+// Extension fields to descriptor.FieldOptions.
+var (
+	// optional protodb.ColumnOptions column = 50601;
+	E_Column = &file_protodbpb_options_proto_extTypes[1]
+)
+
+var File_protodbpb_options_proto protoreflect.FileDescriptor
+
+var file_protodbpb_options_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x70, 0x62, 0x2f, 0x6f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x64, 0x62, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x45, 0x0a, 0x0c, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x65, 0x6c, 0x65,
+	0x63, 0x74, 0x5f, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x22, 0x9e, 0x01, 0x0a, 0x0d,
+	0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6a, 0x6f, 0x69,
+	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6a, 0x6f, 0x69, 0x6e, 0x12, 0x12, 0x0a,
+	0x04, 0x73, 0x6b, 0x69, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x73, 0x6b, 0x69,
+	0x70, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x62, 0x74, 0x61, 0x67, 0x73, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x73, 0x75, 0x62, 0x74, 0x61, 0x67, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x73,
+	0x65, 0x6c, 0x65, 0x63, 0x74, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0a, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x4f, 0x6e, 0x6c, 0x79, 0x3a, 0x4e, 0x0a, 0x05,
+	0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa9, 0x8b, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x2e, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x3a, 0x4f, 0x0a, 0x06,
+	0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa9, 0x8b, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x2e, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x06, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x42, 0x29, 0x5a,
+	0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x64, 0x69,
+	0x64, 0x6f, 0x70, 0x61, 0x67, 0x6f, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protodbpb_options_proto_rawDescOnce sync.Once
+	file_protodbpb_options_proto_rawDescData = file_protodbpb_options_proto_rawDesc
+)
+
+func file_protodbpb_options_proto_rawDescGZIP() []byte {
+	file_protodbpb_options_proto_rawDescOnce.Do(func() {
+		file_protodbpb_options_proto_rawDescData = protoimpl.X.CompressGZIP(file_protodbpb_options_proto_rawDescData)
+	})
+	return file_protodbpb_options_proto_rawDescData
+}
+
+var file_protodbpb_options_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_protodbpb_options_proto_goTypes = []interface{}{
+	(*TableOptions)(nil),              // 0: protodb.TableOptions
+	(*ColumnOptions)(nil),             // 1: protodb.ColumnOptions
+	(*descriptor.MessageOptions)(nil), // 2: google.protobuf.MessageOptions
+	(*descriptor.FieldOptions)(nil),   // 3: google.protobuf.FieldOptions
+}
+var file_protodbpb_options_proto_depIdxs = []int32{
+	2, // 0: protodb.table:extendee -> google.protobuf.MessageOptions
+	3, // 1: protodb.column:extendee -> google.protobuf.FieldOptions
+	0, // 2: protodb.table:type_name -> protodb.TableOptions
+	1, // 3: protodb.column:type_name -> protodb.ColumnOptions
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	2, // [2:4] is the sub-list for extension type_name
+	0, // [0:2] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_protodbpb_options_proto_init() }
+func file_protodbpb_options_proto_init() {
+	if File_protodbpb_options_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protodbpb_options_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TableOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protodbpb_options_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ColumnOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protodbpb_options_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 2,
+			NumServices:   0,
+		},
+		GoTypes:           file_protodbpb_options_proto_goTypes,
+		DependencyIndexes: file_protodbpb_options_proto_depIdxs,
+		MessageInfos:      file_protodbpb_options_proto_msgTypes,
+		ExtensionInfos:    file_protodbpb_options_proto_extTypes,
+	}.Build()
+	File_protodbpb_options_proto = out.File
+	file_protodbpb_options_proto_rawDesc = nil
+	file_protodbpb_options_proto_goTypes = nil
+	file_protodbpb_options_proto_depIdxs = nil
+}
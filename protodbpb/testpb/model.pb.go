@@ -0,0 +1,365 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.15.8
+// source: protodbpb/testpb/model.proto
+
+package testpb
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	_ "github.com/pedidopago/protodb/protodbpb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type CustomerTier int32
+
+const (
+	CustomerTier_CUSTOMER_TIER_UNSPECIFIED CustomerTier = 0
+	CustomerTier_CUSTOMER_TIER_GOLD        CustomerTier = 1
+)
+
+// Enum value maps for CustomerTier.
+var (
+	CustomerTier_name = map[int32]string{
+		0: "CUSTOMER_TIER_UNSPECIFIED",
+		1: "CUSTOMER_TIER_GOLD",
+	}
+	CustomerTier_value = map[string]int32{
+		"CUSTOMER_TIER_UNSPECIFIED": 0,
+		"CUSTOMER_TIER_GOLD":        1,
+	}
+)
+
+func (x CustomerTier) Enum() *CustomerTier {
+	p := new(CustomerTier)
+	*p = x
+	return p
+}
+
+func (x CustomerTier) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CustomerTier) Descriptor() protoreflect.EnumDescriptor {
+	return file_protodbpb_testpb_model_proto_enumTypes[0].Descriptor()
+}
+
+func (CustomerTier) Type() protoreflect.EnumType {
+	return &file_protodbpb_testpb_model_proto_enumTypes[0]
+}
+
+func (x CustomerTier) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CustomerTier.Descriptor instead.
+func (CustomerTier) EnumDescriptor() ([]byte, []int) {
+	return file_protodbpb_testpb_model_proto_rawDescGZIP(), []int{0}
+}
+
+// Customer is mapped by the code generated by protoc-gen-protodb (model.protodb.go).
+type Customer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Types that are assignable to Contact:
+	//	*Customer_Email
+	//	*Customer_Phone
+	Contact   isCustomer_Contact   `protobuf_oneof:"contact"`
+	City      string               `protobuf:"bytes,5,opt,name=city,proto3" json:"city,omitempty"`
+	Tier      CustomerTier         `protobuf:"varint,6,opt,name=tier,proto3,enum=protodb.testpb.CustomerTier" json:"tier,omitempty"`
+	Active    bool                 `protobuf:"varint,7,opt,name=active,proto3" json:"active,omitempty"`
+	Score     float64              `protobuf:"fixed64,8,opt,name=score,proto3" json:"score,omitempty"`
+	Version   int64                `protobuf:"varint,9,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt *timestamp.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Tags      []string             `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	Avatar    []byte               `protobuf:"bytes,12,opt,name=avatar,proto3" json:"avatar,omitempty"`
+}
+
+func (x *Customer) Reset() {
+	*x = Customer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protodbpb_testpb_model_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Customer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Customer) ProtoMessage() {}
+
+func (x *Customer) ProtoReflect() protoreflect.Message {
+	mi := &file_protodbpb_testpb_model_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Customer.ProtoReflect.Descriptor instead.
+func (*Customer) Descriptor() ([]byte, []int) {
+	return file_protodbpb_testpb_model_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Customer) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Customer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (m *Customer) GetContact() isCustomer_Contact {
+	if m != nil {
+		return m.Contact
+	}
+	return nil
+}
+
+func (x *Customer) GetEmail() string {
+	if x, ok := x.GetContact().(*Customer_Email); ok {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Customer) GetPhone() string {
+	if x, ok := x.GetContact().(*Customer_Phone); ok {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *Customer) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Customer) GetTier() CustomerTier {
+	if x != nil {
+		return x.Tier
+	}
+	return CustomerTier_CUSTOMER_TIER_UNSPECIFIED
+}
+
+func (x *Customer) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *Customer) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Customer) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Customer) GetCreatedAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Customer) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Customer) GetAvatar() []byte {
+	if x != nil {
+		return x.Avatar
+	}
+	return nil
+}
+
+type isCustomer_Contact interface {
+	isCustomer_Contact()
+}
+
+type Customer_Email struct {
+	Email string `protobuf:"bytes,3,opt,name=email,proto3,oneof"`
+}
+
+type Customer_Phone struct {
+	Phone string `protobuf:"bytes,4,opt,name=phone,proto3,oneof"`
+}
+
+func (*Customer_Email) isCustomer_Contact() {}
+
+func (*Customer_Phone) isCustomer_Contact() {}
+
+var File_protodbpb_testpb_model_proto protoreflect.FileDescriptor
+
+var file_protodbpb_testpb_model_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x70, 0x62, 0x2f, 0x74, 0x65, 0x73, 0x74,
+	0x70, 0x62, 0x2f, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x70, 0x62, 0x1a, 0x1f,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a,
+	0x17, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x70, 0x62, 0x2f, 0x6f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xc9, 0x04, 0x0a, 0x08, 0x43, 0x75, 0x73,
+	0x74, 0x6f, 0x6d, 0x65, 0x72, 0x12, 0x20, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x42, 0x10, 0xca, 0xda, 0x18, 0x0c, 0x12, 0x05, 0x63, 0x75, 0x2e, 0x69, 0x64, 0x2a, 0x03,
+	0x6b, 0x65, 0x79, 0x52, 0x02, 0x69, 0x64, 0x12, 0x21, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x0d, 0xca, 0xda, 0x18, 0x09, 0x12, 0x07, 0x63, 0x75, 0x2e,
+	0x6e, 0x61, 0x6d, 0x65, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x6d,
+	0x61, 0x69, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x6d, 0x61,
+	0x69, 0x6c, 0x12, 0x16, 0x0a, 0x05, 0x70, 0x68, 0x6f, 0x6e, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x00, 0x52, 0x05, 0x70, 0x68, 0x6f, 0x6e, 0x65, 0x12, 0x54, 0x0a, 0x04, 0x63, 0x69,
+	0x74, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x42, 0x40, 0xca, 0xda, 0x18, 0x3c, 0x12, 0x0f,
+	0x63, 0x69, 0x2e, 0x6e, 0x61, 0x6d, 0x65, 0x20, 0x41, 0x53, 0x20, 0x63, 0x69, 0x74, 0x79, 0x1a,
+	0x27, 0x4c, 0x45, 0x46, 0x54, 0x20, 0x4a, 0x4f, 0x49, 0x4e, 0x20, 0x63, 0x69, 0x74, 0x69, 0x65,
+	0x73, 0x20, 0x63, 0x69, 0x20, 0x4f, 0x4e, 0x20, 0x63, 0x69, 0x2e, 0x69, 0x64, 0x3d, 0x63, 0x75,
+	0x2e, 0x63, 0x69, 0x74, 0x79, 0x5f, 0x69, 0x64, 0x30, 0x01, 0x52, 0x04, 0x63, 0x69, 0x74, 0x79,
+	0x12, 0x3f, 0x0a, 0x04, 0x74, 0x69, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1c,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x70, 0x62, 0x2e,
+	0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x54, 0x69, 0x65, 0x72, 0x42, 0x0d, 0xca, 0xda,
+	0x18, 0x09, 0x12, 0x07, 0x63, 0x75, 0x2e, 0x74, 0x69, 0x65, 0x72, 0x52, 0x04, 0x74, 0x69, 0x65,
+	0x72, 0x12, 0x27, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x08, 0x42, 0x0f, 0xca, 0xda, 0x18, 0x0b, 0x12, 0x09, 0x63, 0x75, 0x2e, 0x61, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x12, 0x24, 0x0a, 0x05, 0x73, 0x63,
+	0x6f, 0x72, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x42, 0x0e, 0xca, 0xda, 0x18, 0x0a, 0x12,
+	0x08, 0x63, 0x75, 0x2e, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65,
+	0x12, 0x33, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x03, 0x42, 0x19, 0xca, 0xda, 0x18, 0x15, 0x12, 0x0a, 0x63, 0x75, 0x2e, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x2a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x5a, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64,
+	0x5f, 0x61, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x42, 0x1f, 0xca, 0xda, 0x18, 0x1b, 0x12, 0x0d, 0x63, 0x75, 0x2e,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x2a, 0x0a, 0x61, 0x75, 0x74, 0x6f,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x1e, 0x0a, 0x06, 0x61, 0x76, 0x61, 0x74, 0x61, 0x72, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x0c, 0x42, 0x06, 0xca, 0xda, 0x18, 0x02, 0x20, 0x01, 0x52, 0x06, 0x61,
+	0x76, 0x61, 0x74, 0x61, 0x72, 0x3a, 0x12, 0xca, 0xda, 0x18, 0x0e, 0x0a, 0x0c, 0x63, 0x75, 0x73,
+	0x74, 0x6f, 0x6d, 0x65, 0x72, 0x73, 0x20, 0x63, 0x75, 0x42, 0x09, 0x0a, 0x07, 0x63, 0x6f, 0x6e,
+	0x74, 0x61, 0x63, 0x74, 0x2a, 0x45, 0x0a, 0x0c, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72,
+	0x54, 0x69, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x19, 0x43, 0x55, 0x53, 0x54, 0x4f, 0x4d, 0x45, 0x52,
+	0x5f, 0x54, 0x49, 0x45, 0x52, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x16, 0x0a, 0x12, 0x43, 0x55, 0x53, 0x54, 0x4f, 0x4d, 0x45, 0x52, 0x5f,
+	0x54, 0x49, 0x45, 0x52, 0x5f, 0x47, 0x4f, 0x4c, 0x44, 0x10, 0x01, 0x42, 0x30, 0x5a, 0x2e, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x64, 0x69, 0x64, 0x6f,
+	0x70, 0x61, 0x67, 0x6f, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x64, 0x62, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x64, 0x62, 0x70, 0x62, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x70, 0x62, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protodbpb_testpb_model_proto_rawDescOnce sync.Once
+	file_protodbpb_testpb_model_proto_rawDescData = file_protodbpb_testpb_model_proto_rawDesc
+)
+
+func file_protodbpb_testpb_model_proto_rawDescGZIP() []byte {
+	file_protodbpb_testpb_model_proto_rawDescOnce.Do(func() {
+		file_protodbpb_testpb_model_proto_rawDescData = protoimpl.X.CompressGZIP(file_protodbpb_testpb_model_proto_rawDescData)
+	})
+	return file_protodbpb_testpb_model_proto_rawDescData
+}
+
+var file_protodbpb_testpb_model_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_protodbpb_testpb_model_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_protodbpb_testpb_model_proto_goTypes = []interface{}{
+	(CustomerTier)(0),           // 0: protodb.testpb.CustomerTier
+	(*Customer)(nil),            // 1: protodb.testpb.Customer
+	(*timestamp.Timestamp)(nil), // 2: google.protobuf.Timestamp
+}
+var file_protodbpb_testpb_model_proto_depIdxs = []int32{
+	0, // 0: protodb.testpb.Customer.tier:type_name -> protodb.testpb.CustomerTier
+	2, // 1: protodb.testpb.Customer.created_at:type_name -> google.protobuf.Timestamp
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_protodbpb_testpb_model_proto_init() }
+func file_protodbpb_testpb_model_proto_init() {
+	if File_protodbpb_testpb_model_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protodbpb_testpb_model_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Customer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_protodbpb_testpb_model_proto_msgTypes[0].OneofWrappers = []interface{}{
+		(*Customer_Email)(nil),
+		(*Customer_Phone)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protodbpb_testpb_model_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_protodbpb_testpb_model_proto_goTypes,
+		DependencyIndexes: file_protodbpb_testpb_model_proto_depIdxs,
+		EnumInfos:         file_protodbpb_testpb_model_proto_enumTypes,
+		MessageInfos:      file_protodbpb_testpb_model_proto_msgTypes,
+	}.Build()
+	File_protodbpb_testpb_model_proto = out.File
+	file_protodbpb_testpb_model_proto_rawDesc = nil
+	file_protodbpb_testpb_model_proto_goTypes = nil
+	file_protodbpb_testpb_model_proto_depIdxs = nil
+}
@@ -0,0 +1,102 @@
+// Code generated by protoc-gen-protodb. DO NOT EDIT.
+// source: protodbpb/testpb/model.proto
+
+package testpb
+
+import (
+	fmt "fmt"
+	protodb "github.com/pedidopago/protodb"
+	reflect "reflect"
+)
+
+// CustomerTable is the table of Customer.
+const CustomerTable = "customers cu"
+
+// CustomerColumns are the columns of Customer.
+var CustomerColumns = []string{"id", "name", "city", "tier", "active", "score", "version", "created_at"}
+
+// CustomerJoins are the joins of the columns of Customer.
+var CustomerJoins = []string{"LEFT JOIN cities ci ON ci.id=cu.city_id"}
+
+var _Customer_columnMeta = []map[string]string{
+	{"key": "", "select": "cu.id", "table": "customers cu"},
+	{"select": "cu.name"},
+	{"join": "LEFT JOIN cities ci ON ci.id=cu.city_id", "select": "ci.name AS city", "selectonly": "true"},
+	{"select": "cu.tier"},
+	{"select": "cu.active"},
+	{"select": "cu.score"},
+	{"select": "cu.version", "version": ""},
+	{"autocreate": "", "select": "cu.created_at"},
+}
+
+// ProtodbColumns implements protodb.Model.
+func (x *Customer) ProtodbColumns(selecting bool) []protodb.TagData {
+	columns := make([]protodb.TagData, 0, 8)
+	columns = append(columns, protodb.TagData{Name: "id", Meta: _Customer_columnMeta[0], FieldName: "Id", FieldValue: reflect.ValueOf(&x.Id).Elem()})
+	columns = append(columns, protodb.TagData{Name: "name", Meta: _Customer_columnMeta[1], FieldName: "Name", FieldValue: reflect.ValueOf(&x.Name).Elem()})
+	if selecting {
+		columns = append(columns, protodb.TagData{Name: "city", Meta: _Customer_columnMeta[2], FieldName: "City", FieldValue: reflect.ValueOf(&x.City).Elem()})
+	}
+	columns = append(columns, protodb.TagData{Name: "tier", Meta: _Customer_columnMeta[3], FieldName: "Tier", FieldValue: reflect.ValueOf(&x.Tier).Elem()})
+	columns = append(columns, protodb.TagData{Name: "active", Meta: _Customer_columnMeta[4], FieldName: "Active", FieldValue: reflect.ValueOf(&x.Active).Elem()})
+	columns = append(columns, protodb.TagData{Name: "score", Meta: _Customer_columnMeta[5], FieldName: "Score", FieldValue: reflect.ValueOf(&x.Score).Elem()})
+	columns = append(columns, protodb.TagData{Name: "version", Meta: _Customer_columnMeta[6], FieldName: "Version", FieldValue: reflect.ValueOf(&x.Version).Elem()})
+	columns = append(columns, protodb.TagData{Name: "created_at", Meta: _Customer_columnMeta[7], FieldName: "CreatedAt", FieldValue: reflect.ValueOf(&x.CreatedAt).Elem()})
+	return columns
+}
+
+// ProtodbScan implements protodb.Model.
+func (x *Customer) ProtodbScan(columns []string) ([]interface{}, error) {
+	dest := make([]interface{}, len(columns))
+	for i, name := range columns {
+		switch name {
+		case "id":
+			dest[i] = protodb.FieldScanner(name, &x.Id)
+		case "name":
+			dest[i] = protodb.FieldScanner(name, &x.Name)
+		case "city":
+			dest[i] = protodb.FieldScanner(name, &x.City)
+		case "tier":
+			dest[i] = protodb.FieldScanner(name, (*int32)(&x.Tier))
+		case "active":
+			dest[i] = protodb.FieldScanner(name, &x.Active)
+		case "score":
+			dest[i] = protodb.FieldScanner(name, &x.Score)
+		case "version":
+			dest[i] = protodb.FieldScanner(name, &x.Version)
+		case "created_at":
+			dest[i] = protodb.FieldScanner(name, &x.CreatedAt)
+		default:
+			return nil, fmt.Errorf("Customer has no column %q", name)
+		}
+	}
+	return dest, nil
+}
+
+// ProtodbValue implements protodb.Model.
+func (x *Customer) ProtodbValue(column string) (interface{}, bool) {
+	switch column {
+	case "id":
+		return x.Id, true
+	case "name":
+		return x.Name, true
+	case "city":
+		return x.City, true
+	case "tier":
+		return x.Tier, true
+	case "active":
+		return x.Active, true
+	case "score":
+		return x.Score, true
+	case "version":
+		return x.Version, true
+	case "created_at":
+		if x.CreatedAt == nil {
+			return nil, true
+		}
+		return x.CreatedAt.AsTime(), true
+	}
+	return nil, false
+}
+
+var _ protodb.Model = (*Customer)(nil)
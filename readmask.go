@@ -0,0 +1,204 @@
+package protodb
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/lann/builder"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// WithReadMask limits the columns selected by GetContext, SelectContext (and the functions built on them)
+// to the fields of mask (e.g. the read_mask of a gRPC Get or List request). The paths are matched like
+// UpdateMaskContext paths (protobuf, JSON or Go names, nested paths or column names).
+//
+// The key ("key", "pk"), "softdelete" and "keyset" columns, the "remapsrc" fields of masked "remapdst"
+// fields and the columns flagged with "readmask=keep" are always selected.
+// A LEFT JOIN is dropped if its column is not selected and its alias is not used by the query (the
+// selected columns, another join or the WHERE, GROUP BY, HAVING and ORDER BY of qfn); other joins
+// (which may filter rows) are always kept.
+// Example:
+//      ctx = protodb.WithReadMask(ctx, req.ReadMask)
+//      err := protodb.SelectContext(ctx, db, &orders, qfn)
+func WithReadMask(ctx context.Context, mask *fieldmaskpb.FieldMask) context.Context {
+	return context.WithValue(ctx, readMaskKey, mask)
+}
+
+// readMask returns the filter of the columns selected by the read mask of ctx (nil if there is no mask)
+func (r ColumnsResult) readMask(ctx context.Context) func(v TagData) bool {
+	mask, _ := ctx.Value(readMaskKey).(*fieldmaskpb.FieldMask)
+	if len(mask.GetPaths()) == 0 || r.typ == nil {
+		return nil
+	}
+	paths := make([][]string, 0, len(mask.GetPaths()))
+	for _, p := range mask.GetPaths() {
+		if p == "*" {
+			return nil
+		}
+		paths = append(paths, strings.Split(p, "."))
+	}
+	matches := func(name string, index []int) bool {
+		for _, p := range paths {
+			if (len(p) == 1 && p[0] == name) || matchMaskPath(r.typ, index, p) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(v TagData) bool {
+		if v.IsKey() || v.MetaFlag("softdelete") || v.MetaString("keyset", "false") != "false" || v.MetaString("readmask", "") == "keep" {
+			return true
+		}
+		if matches(v.Name, v.FieldIndex) {
+			return true
+		}
+		if dst, ok := remapDestination(r.typ, v.FieldIndex); ok {
+			return matches("", dst)
+		}
+		return false
+	}
+}
+
+// remapDestination returns the index of the "remapdst" field filled by the "remapsrc" field index
+func remapDestination(t reflect.Type, index []int) ([]int, bool) {
+	parent := t
+	for _, i := range index[:len(index)-1] {
+		parent = reflectx.Deref(parent.Field(i).Type)
+	}
+	parent = reflectx.Deref(parent)
+	src := parent.Field(index[len(index)-1]).Tag.Get("remapsrc")
+	if src == "" {
+		return nil, false
+	}
+	for i := 0; i < parent.NumField(); i++ {
+		if parent.Field(i).Tag.Get("remapdst") == src {
+			dst := make([]int, len(index))
+			copy(dst, index)
+			dst[len(dst)-1] = i
+			return dst, true
+		}
+	}
+	return nil, false
+}
+
+var joinAliasRe = regexp.MustCompile(`(?i)^(?:.*\bJOIN\s+)?(\S+)(?:\s+(?:AS\s+)?(\w+))?`)
+
+// joinAlias returns the alias (or the table name) of a join clause
+func joinAlias(join string) string {
+	m := joinAliasRe.FindStringSubmatch(strings.TrimSpace(join))
+	if m == nil {
+		return ""
+	}
+	if m[2] != "" && !strings.EqualFold(m[2], "ON") && !strings.EqualFold(m[2], "USING") {
+		return m[2]
+	}
+	return m[1]
+}
+
+// maskJoin is a join clause and whether the column that declares it is selected
+type maskJoin struct {
+	clause   string
+	selected bool
+}
+
+// filterJoins drops the LEFT JOINs that are not referenced by refs (the selected columns, or the query
+// without the joins) or by other kept joins
+func filterJoins(joins []maskJoin, refs string) []string {
+	keep := make([]bool, len(joins))
+	aliases := make([]*regexp.Regexp, len(joins))
+	for i, j := range joins {
+		if alias := joinAlias(j.clause); alias != "" {
+			aliases[i] = regexp.MustCompile(`\b` + regexp.QuoteMeta(alias) + `\.`)
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for i, j := range joins {
+			if keep[i] {
+				continue
+			}
+			if j.selected || !strings.Contains(strings.ToUpper(j.clause), "LEFT ") ||
+				(aliases[i] != nil && aliases[i].MatchString(refs)) {
+				keep[i] = true
+				refs += " " + j.clause
+				changed = true
+			}
+		}
+	}
+	out := make([]string, 0, len(joins))
+	for i, j := range joins {
+		if keep[i] {
+			out = append(out, j.clause)
+		}
+	}
+	return out
+}
+
+// selectJoins returns the joins of the columns and whether each column is selected by the read mask
+// of ctx (every column is selected if there is no mask)
+func (r ColumnsResult) selectJoins(ctx context.Context) []maskJoin {
+	masked := r.readMask(ctx)
+	joins := make([]maskJoin, 0)
+	for _, v := range r.Columns {
+		if v.Meta == nil {
+			continue
+		}
+		if v.RecursiveIf != nil && !contextIfIsTrue(ctx, *v.RecursiveIf, true) {
+			continue
+		}
+		if ifctxv := v.Meta["joinif"]; ifctxv != "" {
+			if vb, ok := ctx.Value(IfKey(ifctxv)).(bool); ok && !vb {
+				continue
+			}
+		}
+		x := v.Meta["select_join"]
+		if x == "" {
+			x = v.Meta["join"]
+		}
+		if x == "" {
+			continue
+		}
+		joins = append(joins, maskJoin{clause: x, selected: masked == nil || masked(v)})
+	}
+	return joins
+}
+
+// pruneJoins drops the LEFT JOINs of the columns (added by selectBuilder) that are not needed by the
+// read mask of ctx. The joins are checked against the whole query (the selected columns and the WHERE,
+// GROUP BY, HAVING and ORDER BY added by qfn), so it must be called after qfn.
+func (r ColumnsResult) pruneJoins(ctx context.Context, rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+	if r.readMask(ctx) == nil {
+		return rq
+	}
+	joins := r.selectJoins(ctx)
+	parts, _ := builder.Get(rq, "Joins")
+	all, _ := parts.([]squirrel.Sqlizer)
+	if len(joins) == 0 || len(all) < len(joins) {
+		return rq
+	}
+	refs, _, err := builder.Delete(rq, "Joins").(squirrel.SelectBuilder).ToSql()
+	if err != nil {
+		return rq
+	}
+	// the joins added by qfn are always kept
+	extra := all[len(joins):]
+	for _, j := range extra {
+		if sql, _, err := j.ToSql(); err == nil {
+			refs += " " + sql
+		}
+	}
+	jr := extractJoinReplace(ctx)
+	for i := range joins {
+		joins[i].clause = mapReplace(joins[i].clause, jr)
+	}
+	rq = builder.Delete(rq, "Joins").(squirrel.SelectBuilder)
+	rq = addJoins(rq, filterJoins(joins, refs))
+	for _, j := range extra {
+		rq = builder.Append(rq, "Joins", j).(squirrel.SelectBuilder)
+	}
+	return rq
+}
@@ -0,0 +1,83 @@
+package protodb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+type readMaskData struct {
+	Raw string
+}
+
+func (d *readMaskData) RemapFrom(src interface{}) error {
+	d.Raw = src.(string)
+	return nil
+}
+
+type readMaskOrder struct {
+	Id           string        `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty" db:"id,table=orders o,select=o.id,key"`
+	Status       string        `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty" db:"status,select=o.status"`
+	TotalCents   int64         `protobuf:"varint,3,opt,name=total_cents,json=totalCents,proto3" json:"total_cents,omitempty" db:"total,select=o.total"`
+	CustomerName string        `protobuf:"bytes,4,opt,name=customer_name,json=customerName,proto3" json:"customer_name,omitempty" db:"customer_name,select=cu.name AS customer_name,join=LEFT JOIN customers cu ON cu.id=o.customer_id"`
+	CustomerCity string        `protobuf:"bytes,5,opt,name=customer_city,json=customerCity,proto3" json:"customer_city,omitempty" db:"customer_city,select=ci.name AS customer_city,join=LEFT JOIN cities ci ON ci.id=cu.city_id"`
+	StoreName    string        `protobuf:"bytes,6,opt,name=store_name,json=storeName,proto3" json:"store_name,omitempty" db:"store_name,select=s.name AS store_name,join=JOIN stores s ON s.id=o.store_id"`
+	RawData      string        `db:"raw_data,select=o.raw_data" remapsrc:"data" json:"-"`
+	Data         *readMaskData `protobuf:"bytes,7,opt,name=data,proto3" json:"data,omitempty" remapdst:"data"`
+}
+
+func TestSelectReadMask(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+	joins := "LEFT JOIN customers cu ON cu.id=o.customer_id LEFT JOIN cities ci ON ci.id=cu.city_id JOIN stores s ON s.id=o.store_id"
+
+	// no mask: every column and join
+	mock.ExpectQuery("^SELECT o.id, o.status, o.total, cu.name AS customer_name, ci.name AS customer_city, s.name AS store_name, o.raw_data FROM orders o " + joins + "$").
+		WillReturnRows(mock.NewRows([]string{"id"}))
+	items := make([]*readMaskOrder, 0)
+	require.NoError(t, protodb.SelectContext(ctx, db, &items, nil))
+
+	// the key column stays; the unneeded LEFT JOINs are dropped, the inner JOIN is kept
+	mock.ExpectQuery("^SELECT o.id, o.total FROM orders o JOIN stores s ON s.id=o.store_id$").
+		WillReturnRows(mock.NewRows([]string{"id", "total"}).AddRow("o1", 100))
+	mctx := protodb.WithReadMask(ctx, &fieldmaskpb.FieldMask{Paths: []string{"totalCents"}})
+	require.NoError(t, protodb.SelectContext(mctx, db, &items, nil))
+	require.Len(t, items, 1)
+	require.Equal(t, int64(100), items[0].TotalCents)
+
+	// a join needed by another join is kept; remapdst paths select the remapsrc column
+	mock.ExpectQuery("^SELECT o.id, ci.name AS customer_city, o.raw_data FROM orders o " + joins + "$").
+		WillReturnRows(mock.NewRows([]string{"id", "customer_city", "raw_data"}).AddRow("o1", "Recife", `{"name":"x"}`))
+	item := &readMaskOrder{}
+	mctx = protodb.WithReadMask(ctx, &fieldmaskpb.FieldMask{Paths: []string{"customer_city", "data"}})
+	require.NoError(t, protodb.GetContext(mctx, db, item, nil))
+	require.Equal(t, "Recife", item.CustomerCity)
+	require.Equal(t, `{"name":"x"}`, item.Data.Raw)
+
+	// the aliases used by qfn (WHERE, ORDER BY) keep their joins
+	mock.ExpectQuery("^SELECT o.id, o.total FROM orders o " + joins + " WHERE ci.name = \\?$").WithArgs("Recife").
+		WillReturnRows(mock.NewRows([]string{"id", "total"}).AddRow("o1", 100))
+	mctx = protodb.WithReadMask(ctx, &fieldmaskpb.FieldMask{Paths: []string{"total_cents"}})
+	require.NoError(t, protodb.SelectContext(mctx, db, &items, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("ci.name = ?", "Recife")
+	}))
+	mock.ExpectQuery("^SELECT o.id, o.total FROM orders o LEFT JOIN customers cu ON cu.id=o.customer_id JOIN stores s ON s.id=o.store_id ORDER BY cu.name$").
+		WillReturnRows(mock.NewRows([]string{"id", "total"}).AddRow("o1", 100))
+	require.NoError(t, protodb.SelectContext(mctx, db, &items, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.OrderBy("cu.name")
+	}))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM orders o LEFT JOIN customers cu ON cu.id=o.customer_id LEFT JOIN cities ci ON ci.id=cu.city_id JOIN stores s ON s.id=o.store_id WHERE ci.name = \\?$").
+		WithArgs("Recife").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+	_, err := protodb.CountContext(mctx, db, &items, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("ci.name = ?", "Recife")
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,115 @@
+package protodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RetryPolicy configures the retries of WrapRetryContext
+type RetryPolicy struct {
+	MaxAttempts int              // max number of attempts (default 3)
+	BaseDelay   time.Duration    // delay before the first retry (default 10ms); doubled on each retry
+	MaxDelay    time.Duration    // max delay between attempts (default 1s)
+	Retryable   func(error) bool // tests if an error can be retried (default IsRetryable)
+	// OnRetry (optional) is called before waiting to retry a failed attempt
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// RetryStats reports the attempts made by WrapRetryContext
+type RetryStats struct {
+	Attempts int     // number of attempts (1 if the first one succeeded)
+	Errors   []error // errors of the failed attempts (including the last one)
+}
+
+// retryCanceledError is returned by WrapRetryContext when ctx is done while waiting to retry; it
+// matches ctx.Err() with errors.Is and unwraps to the error of the last attempt
+type retryCanceledError struct {
+	ctxErr error
+	err    error
+}
+
+func (e *retryCanceledError) Error() string {
+	return e.ctxErr.Error() + " (last attempt: " + e.err.Error() + ")"
+}
+func (e *retryCanceledError) Unwrap() error        { return e.err }
+func (e *retryCanceledError) Is(target error) bool { return errors.Is(e.ctxErr, target) }
+
+// IsRetryable tests if err is a deadlock, a lock wait timeout or a serialization failure
+// (the transaction can succeed if it is executed again).
+func IsRetryable(err error) bool {
+	return IsDeadlock(err) || IsSerializationFailure(err) || IsLockTimeout(err)
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 3
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 10 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = time.Second
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsRetryable
+	}
+	return p
+}
+
+// delay returns the wait before the retry of attempt n (exponential backoff with full jitter)
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// WrapRetryContext runs fn inside a transaction like WrapContext. If the transaction fails with a
+// retryable error (see RetryPolicy.Retryable), it is rolled back and fn is executed again in a new
+// transaction, waiting between attempts (exponential backoff with jitter). The errors returned by fn
+// and by the commit are classified with ClassifyError.
+//
+// It stops when ctx is done; the returned error then matches ctx.Err() (errors.Is) and wraps the
+// error of the last attempt. If ctx already holds a transaction (nested call), fn can't be retried
+// alone, so it runs once inside a savepoint.
+func WrapRetryContext(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, policy RetryPolicy, fn func(ctx context.Context, tx *sqlx.Tx) error) (RetryStats, error) {
+	policy = policy.withDefaults()
+	stats := RetryStats{}
+	if txStateFromContext(ctx) != nil {
+		stats.Attempts = 1
+		return stats, WrapContext(ctx, db, opts, fn)
+	}
+	for {
+		stats.Attempts++
+		err := ClassifyError(WrapContext(ctx, db, opts, func(ctx context.Context, tx *sqlx.Tx) error {
+			return ClassifyError(fn(ctx, tx))
+		}))
+		if err == nil {
+			return stats, nil
+		}
+		stats.Errors = append(stats.Errors, err)
+		if stats.Attempts >= policy.MaxAttempts || !policy.Retryable(err) {
+			return stats, err
+		}
+		delay := policy.delay(stats.Attempts)
+		if policy.OnRetry != nil {
+			policy.OnRetry(stats.Attempts, err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return stats, &retryCanceledError{ctxErr: ctx.Err(), err: err}
+		case <-timer.C:
+		}
+	}
+}
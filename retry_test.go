@@ -0,0 +1,96 @@
+package protodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapRetryContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	retries := 0
+	calls := 0
+	stats, err := protodb.WrapRetryContext(ctx, db, nil, protodb.RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			retries++
+			require.True(t, protodb.IsDeadlock(err))
+			require.LessOrEqual(t, delay, time.Second)
+		},
+	}, func(ctx context.Context, tx *sqlx.Tx) error {
+		calls++
+		if calls < 3 {
+			return &mysqlError{1213, "Deadlock found when trying to get lock"}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, stats.Attempts)
+	require.Len(t, stats.Errors, 2)
+	require.Equal(t, 2, retries)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWrapRetryContextStops(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	// not retryable
+	errFail := errors.New("fail")
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	stats, err := protodb.WrapRetryContext(context.Background(), db, nil, protodb.RetryPolicy{}, func(ctx context.Context, tx *sqlx.Tx) error {
+		return errFail
+	})
+	require.Equal(t, errFail, err)
+	require.Equal(t, 1, stats.Attempts)
+	require.Equal(t, []error{errFail}, stats.Errors)
+
+	// max attempts
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	stats, err = protodb.WrapRetryContext(context.Background(), db, nil, protodb.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}, func(ctx context.Context, tx *sqlx.Tx) error {
+		return &pgError{Code: "40001"}
+	})
+	require.True(t, protodb.IsSerializationFailure(err))
+	require.Equal(t, 2, stats.Attempts)
+	require.Len(t, stats.Errors, 2)
+
+	// context canceled while waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	stats, err = protodb.WrapRetryContext(ctx, db, nil, protodb.RetryPolicy{
+		BaseDelay: time.Hour,
+		MaxDelay:  time.Hour,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			cancel()
+		},
+	}, func(ctx context.Context, tx *sqlx.Tx) error {
+		return &mysqlError{1213, "Deadlock found when trying to get lock"}
+	})
+	require.True(t, errors.Is(err, context.Canceled))
+	require.True(t, protodb.IsDeadlock(err))
+	require.Equal(t, 1, stats.Attempts)
+	require.Len(t, stats.Errors, 1)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
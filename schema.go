@@ -0,0 +1,294 @@
+package protodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// schemaType is the column type of a Go type in each dialect
+type schemaType struct {
+	mysql    string
+	postgres string
+	nullable bool
+}
+
+func (t schemaType) of(d Dialect) string {
+	if d == PostgreSQL {
+		return t.postgres
+	}
+	return t.mysql
+}
+
+var (
+	schemaTime   = schemaType{"DATETIME(6)", "TIMESTAMP WITH TIME ZONE", false}
+	schemaString = schemaType{"VARCHAR(255)", "TEXT", false}
+	schemaJSON   = schemaType{"JSON", "JSONB", false}
+)
+
+// schemaTypes are the column types of the types that are not inferred by their kind
+var schemaTypes = map[reflect.Type]schemaType{
+	reflect.TypeOf(time.Time{}):              schemaTime,
+	reflect.TypeOf(timestamppb.Timestamp{}):  schemaTime,
+	reflect.TypeOf(sql.NullTime{}):           {schemaTime.mysql, schemaTime.postgres, true},
+	reflect.TypeOf(sql.NullString{}):         {schemaString.mysql, schemaString.postgres, true},
+	reflect.TypeOf(sql.NullInt64{}):          {"BIGINT", "BIGINT", true},
+	reflect.TypeOf(sql.NullInt32{}):          {"INT", "INTEGER", true},
+	reflect.TypeOf(sql.NullFloat64{}):        {"DOUBLE", "DOUBLE PRECISION", true},
+	reflect.TypeOf(sql.NullBool{}):           {"BOOLEAN", "BOOLEAN", true},
+	reflect.TypeOf(wrapperspb.StringValue{}): {schemaString.mysql, schemaString.postgres, true},
+	reflect.TypeOf(wrapperspb.BytesValue{}):  {"BLOB", "BYTEA", true},
+	reflect.TypeOf(wrapperspb.BoolValue{}):   {"BOOLEAN", "BOOLEAN", true},
+	reflect.TypeOf(wrapperspb.Int32Value{}):  {"INT", "INTEGER", true},
+	reflect.TypeOf(wrapperspb.Int64Value{}):  {"BIGINT", "BIGINT", true},
+	reflect.TypeOf(wrapperspb.UInt32Value{}): {"INT UNSIGNED", "BIGINT", true},
+	reflect.TypeOf(wrapperspb.UInt64Value{}): {"BIGINT UNSIGNED", "NUMERIC(20)", true},
+	reflect.TypeOf(wrapperspb.FloatValue{}):  {"FLOAT", "REAL", true},
+	reflect.TypeOf(wrapperspb.DoubleValue{}): {"DOUBLE", "DOUBLE PRECISION", true},
+	reflect.TypeOf(structpb.Struct{}):        schemaJSON,
+	reflect.TypeOf(structpb.Value{}):         schemaJSON,
+	reflect.TypeOf(structpb.ListValue{}):     schemaJSON,
+}
+
+// schemaKinds are the column types inferred by the kind of a Go type
+var schemaKinds = map[reflect.Kind]schemaType{
+	reflect.Bool:    {"BOOLEAN", "BOOLEAN", false},
+	reflect.Int8:    {"TINYINT", "SMALLINT", false},
+	reflect.Int16:   {"SMALLINT", "SMALLINT", false},
+	reflect.Int32:   {"INT", "INTEGER", false},
+	reflect.Int:     {"BIGINT", "BIGINT", false},
+	reflect.Int64:   {"BIGINT", "BIGINT", false},
+	reflect.Uint8:   {"TINYINT UNSIGNED", "SMALLINT", false},
+	reflect.Uint16:  {"SMALLINT UNSIGNED", "INTEGER", false},
+	reflect.Uint32:  {"INT UNSIGNED", "BIGINT", false},
+	reflect.Uint:    {"BIGINT UNSIGNED", "NUMERIC(20)", false},
+	reflect.Uint64:  {"BIGINT UNSIGNED", "NUMERIC(20)", false},
+	reflect.Float32: {"FLOAT", "REAL", false},
+	reflect.Float64: {"DOUBLE", "DOUBLE PRECISION", false},
+	reflect.String:  schemaString,
+	reflect.Map:     schemaJSON,
+	reflect.Slice:   schemaJSON,
+	reflect.Struct:  schemaJSON,
+}
+
+// schemaColumn is a column of SchemaFor
+type schemaColumn struct {
+	TagData
+	typ      reflect.Type // the field type
+	nullable bool         // true if a struct of the field path is a pointer
+}
+
+// SchemaFor returns the CREATE TABLE statement (and the CREATE INDEX statements) of the table of model
+// (a struct, a pointer to a struct or a protobuf message), e.g. to create the tables of the tests.
+// The columns are the InsertColumnScan columns of model (the select only columns are not included).
+//
+// The column types are inferred from the Go types (string: VARCHAR(255)/TEXT, int64: BIGINT, time.Time and
+// google.protobuf.Timestamp: DATETIME(6)/TIMESTAMP WITH TIME ZONE, maps, slices, other messages and
+// sql.Scanner structs: JSON/JSONB...). Pointers, sql.Null* and google.protobuf wrapper types are nullable,
+// as are the columns of nested struct pointers.
+// Valid subtags:
+//   - "type": the column type (e.g. `db:"total,type=DECIMAL(10,2)"`).
+//   - "key", "pk": the column is a part of the PRIMARY KEY (and is NOT NULL).
+//   - "autoinc": AUTO_INCREMENT (MySQL) or GENERATED BY DEFAULT AS IDENTITY (PostgreSQL).
+//   - "null", "notnull": the column is (or is not) nullable, regardless of the Go type.
+//   - "default": the DEFAULT expression of the column (e.g. `db:"status,default='new'"`).
+//   - "index", "unique": the column is indexed (CREATE INDEX or CREATE UNIQUE INDEX). The columns with
+//                        the same index name (e.g. `index=orders_store_date`) are a composite index.
+// Example:
+//      type Order struct {
+//         ID        int64     `db:"id,table=orders,key,autoinc"`
+//         StoreID   string    `db:"store_id,type=CHAR(26),index=orders_store_created"`
+//         Status    string    `db:"status,default='new'"`
+//         CreatedAt time.Time `db:"created_at,index=orders_store_created"`
+//      }
+//      // CREATE TABLE orders (
+//      //   id BIGINT NOT NULL AUTO_INCREMENT,
+//      //   store_id CHAR(26) NOT NULL,
+//      //   status VARCHAR(255) NOT NULL DEFAULT 'new',
+//      //   created_at DATETIME(6) NOT NULL,
+//      //   PRIMARY KEY (id)
+//      // );
+//      // CREATE INDEX orders_store_created ON orders (store_id, created_at);
+//      schema, err := protodb.SchemaFor(&Order{}, protodb.MySQL)
+func SchemaFor(model interface{}, dialect Dialect) (string, error) {
+	t := structType(model)
+	if t == nil {
+		return "", errors.New("(schema) model is not a struct")
+	}
+	columns, err := schemaColumns(t)
+	if err != nil {
+		return "", err
+	}
+	table := ""
+	for _, c := range columns {
+		if table = c.Meta["table"]; table != "" {
+			break
+		}
+	}
+	if table == "" {
+		table = ColumnsResult{Columns: columnsTagData(columns)}.GetTableNameMeta(context.Background())
+	}
+	if table = tableName(table); table == "" {
+		return "", errors.New("(schema) subtag 'table' not found")
+	}
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "CREATE TABLE %s (\n", table)
+	defs := make([]string, 0, len(columns)+1)
+	keys := make([]string, 0)
+	indexes := make([]string, 0)
+	indexColumns := make(map[string][]string)
+	indexUnique := make(map[string]bool)
+	for _, c := range columns {
+		def, err := columnDefinition(dialect, c)
+		if err != nil {
+			return "", err
+		}
+		defs = append(defs, def)
+		if c.IsKey() {
+			keys = append(keys, dialect.column(c.Name))
+		}
+		for _, kind := range []string{"index", "unique"} {
+			name, ok := c.MetaStringCheck(kind)
+			if !ok || name == "false" {
+				continue
+			}
+			if name == "" || name == "true" {
+				name = table + "_" + c.Name + "_idx"
+				if kind == "unique" {
+					name = table + "_" + c.Name + "_key"
+				}
+			}
+			if _, ok := indexColumns[name]; !ok {
+				indexes = append(indexes, name)
+			}
+			indexColumns[name] = append(indexColumns[name], dialect.column(c.Name))
+			indexUnique[name] = indexUnique[name] || kind == "unique"
+		}
+	}
+	if len(keys) > 0 {
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+	}
+	b.WriteString("  " + strings.Join(defs, ",\n  ") + "\n);\n")
+	for _, name := range indexes {
+		create := "CREATE INDEX"
+		if indexUnique[name] {
+			create = "CREATE UNIQUE INDEX"
+		}
+		fmt.Fprintf(b, "%s %s ON %s (%s);\n", create, name, table, strings.Join(indexColumns[name], ", "))
+	}
+	return b.String(), nil
+}
+
+// schemaColumns returns the insert columns of the struct type t (including the columns of nil nested structs)
+func schemaColumns(t reflect.Type) ([]schemaColumn, error) {
+	x := make([]schemaColumn, 0)
+	seen := make(map[string]bool)
+	add := func(c schemaColumn) {
+		if c.Name == "-" || c.Name == "" || c.MetaFlag("selectonly") || seen[c.Name] {
+			return
+		}
+		seen[c.Name] = true
+		x = append(x, c)
+	}
+	if r, ok := protoColumnScan(reflect.New(t), false); ok {
+		for _, v := range r.Columns {
+			add(schemaColumn{TagData: v, typ: v.FieldValue.Type()})
+		}
+		return x, nil
+	}
+	for _, f := range cachedTypeMeta(t, map[string]string{"db": ","}, []string{"db_insert", "dbinsert", "insert", "db"}) {
+		c := schemaColumn{
+			TagData: TagData{
+				Name:       f.name,
+				Meta:       f.meta,
+				FieldName:  f.fieldName,
+				FieldIndex: f.index,
+			},
+		}
+		ft := t
+		for i, idx := range f.index {
+			if i > 0 && ft.Kind() == reflect.Ptr {
+				c.nullable = true
+				ft = ft.Elem()
+			}
+			ft = ft.Field(idx).Type
+		}
+		c.typ = ft
+		add(c)
+	}
+	if len(x) == 0 {
+		return nil, fmt.Errorf("(schema) %v has no columns", t)
+	}
+	return x, nil
+}
+
+// columnsTagData returns the TagData of the columns
+func columnsTagData(columns []schemaColumn) []TagData {
+	x := make([]TagData, len(columns))
+	for i, c := range columns {
+		x[i] = c.TagData
+	}
+	return x
+}
+
+// columnDefinition returns the definition of a column in the CREATE TABLE statement
+func columnDefinition(dialect Dialect, c schemaColumn) (string, error) {
+	typ, nullable, err := columnType(dialect, c.typ)
+	if v := c.MetaString("type", ""); v != "" {
+		// the type is only used to infer if the column is nullable
+		typ = v
+		if err != nil {
+			nullable = c.typ.Kind() == reflect.Ptr || c.typ.Kind() == reflect.Interface
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("(schema) column %s: %w (use the 'type' subtag)", c.Name, err)
+	}
+	nullable = (nullable || c.nullable || c.MetaFlag("null")) && !c.MetaFlag("notnull") && !c.IsKey()
+	def := dialect.column(c.Name) + " " + typ
+	if !nullable {
+		def += " NOT NULL"
+	}
+	if c.MetaFlag("autoinc") {
+		if dialect == PostgreSQL {
+			def += " GENERATED BY DEFAULT AS IDENTITY"
+		} else {
+			def += " AUTO_INCREMENT"
+		}
+	}
+	if v := c.MetaString("default", ""); v != "" {
+		def += " DEFAULT " + v
+	}
+	return def, nil
+}
+
+// columnType infers the column type of the Go type t
+func columnType(dialect Dialect, t reflect.Type) (string, bool, error) {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		nullable = true
+		t = t.Elem()
+	}
+	if st, ok := schemaTypes[t]; ok {
+		return st.of(dialect), nullable || st.nullable, nil
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return schemaType{"BLOB", "BYTEA", false}.of(dialect), nullable, nil
+	}
+	if t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(reflect.TypeOf((*proto.Message)(nil)).Elem()) &&
+		!reflect.PtrTo(t).Implements(reflect.TypeOf((*sql.Scanner)(nil)).Elem()) {
+		return "", false, fmt.Errorf("cannot infer the type of %v", t)
+	}
+	if st, ok := schemaKinds[t.Kind()]; ok {
+		return st.of(dialect), nullable || st.nullable, nil
+	}
+	return "", false, fmt.Errorf("cannot infer the type of %v", t)
+}
@@ -0,0 +1,158 @@
+package protodb_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/pedidopago/protodb"
+	"github.com/pedidopago/protodb/protodbpb/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type schemaOrder struct {
+	ID        int64     `db:"id,table=orders,key,autoinc"`
+	StoreID   string    `db:"store_id,type=CHAR(26),index=orders_store_created"`
+	Status    string    `db:"status,default='new'"`
+	CreatedAt time.Time `db:"created_at,index=orders_store_created"`
+}
+
+type schemaAddress struct {
+	Street string `db:"address_street"`
+	City   string `db:"address_city,index"`
+}
+
+// schemaPoint is a struct that is not a sql.Scanner (its type cannot be inferred)
+type schemaPoint struct {
+	X, Y float64
+}
+
+type schemaCustomer struct {
+	ID       string                 `db:"id,table=customers c,key"`
+	Email    string                 `db:"email,unique"`
+	Total    float64                `db:"total,type=DECIMAL(10,2),default=0"`
+	Nick     *string                `db:"nick"`
+	Phone    sql.NullString         `db:"phone"`
+	Score    *wrapperspb.Int64Value `db:"score"`
+	Age      uint8                  `db:"age,null"`
+	Tags     []string               `db:"tags"`
+	Avatar   []byte                 `db:"avatar"`
+	Order    int                    `db:"order"`
+	City     string                 `db:"city,select=ci.name AS city,selectonly"`
+	Address  *schemaAddress         `db:"-"`
+	ReadOnly string                 `dbselect:"read_only"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema, err := protodb.SchemaFor(&schemaOrder{}, protodb.MySQL)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE orders (
+  id BIGINT NOT NULL AUTO_INCREMENT,
+  store_id CHAR(26) NOT NULL,
+  status VARCHAR(255) NOT NULL DEFAULT 'new',
+  created_at DATETIME(6) NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE INDEX orders_store_created ON orders (store_id, created_at);
+`, schema)
+
+	schema, err = protodb.SchemaFor(schemaOrder{}, protodb.PostgreSQL)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE orders (
+  id BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+  store_id CHAR(26) NOT NULL,
+  status TEXT NOT NULL DEFAULT 'new',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE INDEX orders_store_created ON orders (store_id, created_at);
+`, schema)
+}
+
+func TestSchemaForTypes(t *testing.T) {
+	schema, err := protodb.SchemaFor(&schemaCustomer{}, protodb.MySQL)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE customers (
+  id VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  total DECIMAL(10,2) NOT NULL DEFAULT 0,
+  nick VARCHAR(255),
+  phone VARCHAR(255),
+  score BIGINT,
+  age TINYINT UNSIGNED,
+  tags JSON NOT NULL,
+  avatar BLOB NOT NULL,
+  `+"`order`"+` BIGINT NOT NULL,
+  address_street VARCHAR(255),
+  address_city VARCHAR(255),
+  PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX customers_email_key ON customers (email);
+CREATE INDEX customers_address_city_idx ON customers (address_city);
+`, schema)
+
+	schema, err = protodb.SchemaFor(&schemaCustomer{}, protodb.PostgreSQL)
+	require.NoError(t, err)
+	require.Contains(t, schema, "  age SMALLINT,\n  tags JSONB NOT NULL,\n  avatar BYTEA NOT NULL,\n  \"order\" BIGINT NOT NULL,\n")
+}
+
+func TestSchemaForProto(t *testing.T) {
+	// protodb options (protoreflect)
+	schema, err := protodb.SchemaFor(&testpb.Order{}, protodb.PostgreSQL)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE orders (
+  id TEXT NOT NULL,
+  status INTEGER NOT NULL,
+  total BIGINT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE,
+  PRIMARY KEY (id)
+);
+`, schema)
+
+	// generated by protoc-gen-protodb
+	schema, err = protodb.SchemaFor((*testpb.Customer)(nil), protodb.MySQL)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE customers (
+  id VARCHAR(255) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  tier INT NOT NULL,
+  active BOOLEAN NOT NULL,
+  score DOUBLE NOT NULL,
+  version BIGINT NOT NULL,
+  created_at DATETIME(6),
+  PRIMARY KEY (id)
+);
+`, schema)
+}
+
+func TestSchemaForErrors(t *testing.T) {
+	_, err := protodb.SchemaFor(&struct {
+		ID string `db:"id"`
+	}{}, protodb.MySQL)
+	require.EqualError(t, err, "(schema) subtag 'table' not found")
+
+	_, err = protodb.SchemaFor(&struct {
+		ID   string      `db:"id,table=items"`
+		Data interface{} `db:"data"`
+	}{}, protodb.MySQL)
+	require.EqualError(t, err, "(schema) column data: cannot infer the type of interface {} (use the 'type' subtag)")
+
+	// the type subtag is used when the type cannot be inferred
+	schema, err := protodb.SchemaFor(&struct {
+		ID    string      `db:"id,table=items,key"`
+		Data  interface{} `db:"data,type=JSON"`
+		Point schemaPoint `db:"point,type=POINT"`
+	}{}, protodb.MySQL)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE items (
+  id VARCHAR(255) NOT NULL,
+  data JSON,
+  point POINT NOT NULL,
+  PRIMARY KEY (id)
+);
+`, schema)
+
+	_, err = protodb.SchemaFor("items", protodb.MySQL)
+	require.Error(t, err)
+}
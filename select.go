@@ -2,6 +2,7 @@ package protodb
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
@@ -17,6 +18,20 @@ import (
 type ColumnsResult struct {
 	Err     error
 	Columns []TagData
+	typ     reflect.Type // the scanned struct type (set by SelectColumnScan)
+	// protoScan is true if the columns were mapped by ProtoColumnScan (the rows are scanned by position)
+	protoScan bool
+	model     Model // set if the columns were generated by protoc-gen-protodb
+}
+
+// column returns the field value of the column name
+func (r ColumnsResult) column(name string) (reflect.Value, bool) {
+	for _, v := range r.Columns {
+		if v.Name == name {
+			return v.FieldValue, true
+		}
+	}
+	return reflect.Value{}, false
 }
 
 type ConditionalContextKey string
@@ -44,13 +59,32 @@ func contextIfIsTrue(ctx context.Context, name ConditionalContextKey, defaultv b
 // Options:
 //   - "joinif": the value will be interpreted as a ConditionalContextKey and will be
 //               evaluated with the context.Value(ConditionalContextKey(joinifKey))
+// If ctx has a read mask (see WithReadMask), only the masked columns are selected.
 func (r ColumnsResult) SelectColumns(ctx context.Context) []string {
-	cols := make([]string, 0)
+	columns := r.selectedColumns(ctx)
+	cols := make([]string, len(columns))
+	for i, v := range columns {
+		if v.Meta != nil && v.Meta["select"] != "" {
+			cols[i] = v.Meta["select"]
+		} else {
+			cols[i] = v.Name
+		}
+	}
+	return cols
+}
+
+// selectedColumns returns the columns selected by SelectColumns (in the same order)
+func (r ColumnsResult) selectedColumns(ctx context.Context) []TagData {
+	cols := make([]TagData, 0)
+	masked := r.readMask(ctx)
 	for _, v := range r.Columns {
 		isok := true
 		if v.RecursiveIf != nil && !contextIfIsTrue(ctx, *v.RecursiveIf, true) {
 			continue
 		}
+		if masked != nil && !masked(v) {
+			continue
+		}
 		if ifctxv := v.Meta["if"]; ifctxv != "" {
 			if vi := ctx.Value(IfKey(ifctxv)); vi != nil {
 				if vb, ok := vi.(bool); ok {
@@ -59,15 +93,13 @@ func (r ColumnsResult) SelectColumns(ctx context.Context) []string {
 			}
 		}
 		if isok {
-			if v.Meta != nil && v.Meta["select"] != "" {
-				cols = append(cols, v.Meta["select"])
-			} else {
+			if v.Meta == nil || v.Meta["select"] == "" {
 				//TODO: workaround if v.Value == ""
 				if v.Name == "-" || v.Name == "" {
 					continue
 				}
-				cols = append(cols, v.Name)
 			}
+			cols = append(cols, v)
 		}
 	}
 	return cols
@@ -118,41 +150,30 @@ func (r ColumnsResult) GetTableNameMeta(ctx context.Context) string {
 // Options:
 //   - "joinif": the value will be interpreted as a ConditionalContextKey and will be
 //               evaluated with the context.Value(ConditionalContextKey(joinifKey))
+// If ctx has a read mask (see WithReadMask), the LEFT JOINs that are not needed by the selected
+// columns are dropped (the queries of GetContext, SelectContext... also check the clauses of qfn).
 func (r ColumnsResult) SelectJoins(ctx context.Context) []string {
-	joins := make([]string, 0)
-	for _, v := range r.Columns {
-		if v.Meta == nil {
-			continue
-		}
-		if v.RecursiveIf != nil && !contextIfIsTrue(ctx, *v.RecursiveIf, true) {
-			continue
-		}
-		isok := true
-		if ifctxv := v.Meta["joinif"]; ifctxv != "" {
-			if vi := ctx.Value(IfKey(ifctxv)); vi != nil {
-				if vb, ok := vi.(bool); ok {
-					isok = vb
-				}
-			}
-		}
-		if isok {
-			if x := v.Meta["select_join"]; x != "" {
-				joins = append(joins, x)
-			} else if x := v.Meta["join"]; x != "" {
-				joins = append(joins, x)
-			}
-		}
+	joins := r.selectJoins(ctx)
+	if r.readMask(ctx) != nil {
+		return filterJoins(joins, strings.Join(r.SelectColumns(ctx), " "))
+	}
+	x := make([]string, len(joins))
+	for i, j := range joins {
+		x[i] = j.clause
 	}
-	return joins
+	return x
 }
 
-// TagData is a collection of metadata and value, retrieved by parsing the tags of a field
+// TagData is a collection of metadata and value, retrieved by parsing the tags of a field.
+// The parsed tags are cached by type: FieldIndex is shared and must not be modified.
 type TagData struct {
 	Name        string
 	Meta        map[string]string
 	FieldName   string
 	FieldValue  reflect.Value
+	FieldIndex  []int // index path of the field from the scanned struct
 	RecursiveIf *ConditionalContextKey
+	model       Model // set if the column was generated by protoc-gen-protodb (see resolveValue)
 }
 
 func (d *TagData) MetaBool(name string, defaultv bool) bool {
@@ -167,6 +188,20 @@ func (d *TagData) MetaBool(name string, defaultv bool) bool {
 	return defaultv
 }
 
+// MetaFlag returns true if the subtag is set without a value (e.g. "key") or with a true
+// value (e.g. "key=true"). Unlike MetaBool, a subtag without a value is true.
+func (d *TagData) MetaFlag(name string) bool {
+	if v, ok := d.MetaStringCheck(name); ok && v == "" {
+		return true
+	}
+	return d.MetaBool(name, false)
+}
+
+// IsKey returns true if the column is flagged with the "key" or "pk" subtag.
+func (d *TagData) IsKey() bool {
+	return d.MetaFlag("key") || d.MetaFlag("pk")
+}
+
 func (d *TagData) MetaStringCheck(name string) (string, bool) {
 	if d.Meta == nil {
 		return "", false
@@ -187,12 +222,44 @@ func (d *TagData) MetaString(name string, defaultv string) string {
 
 // SelectColumnScan uses db_select, dbselect, db (in this order) to map columns to be selected
 func SelectColumnScan(v interface{}, tags ...string) ColumnsResult {
+	if r, ok := protoColumnScan(v, true); ok {
+		return r
+	}
 	tags = append(tags, "db_select", "dbselect", "db")
 	result, err := extract(v, map[string]string{"db": ","}, tags...)
 	return ColumnsResult{
 		Err:     err,
 		Columns: result,
+		typ:     structType(v),
+	}
+}
+
+// structType returns the struct type of v (a struct, a pointer to a struct or a reflect.Value)
+func structType(v interface{}) reflect.Type {
+	vval, ok := v.(reflect.Value)
+	if !ok {
+		vval = reflect.ValueOf(v)
+	}
+	if !vval.IsValid() {
+		return nil
+	}
+	if t := reflectx.Deref(vval.Type()); t.Kind() == reflect.Struct {
+		return t
+	}
+	return nil
+}
+
+// modelColumnScan executes a SelectColumnScan on a struct (or a pointer to a struct or to a slice of structs)
+func modelColumnScan(model interface{}) ColumnsResult {
+	value := reflect.ValueOf(model)
+	if isNilSafe(value) {
+		return ColumnsResult{Err: errors.New("model is nil")}
+	}
+	if isTypeSliceOrSlicePointer(value.Type()) {
+		slice := reflectx.Deref(value.Type())
+		return SelectColumnScan(reflect.New(reflectx.Deref(slice.Elem())))
 	}
+	return SelectColumnScan(value)
 }
 
 // errIfNotAPointerOrNil returns an error if value is not a pointer or is nil
@@ -208,13 +275,14 @@ func errIfNotAPointerOrNil(value reflect.Value) error {
 
 // GetContext executes a SelectColumnScan on dest (with reflection) to determine which table, columns and joins are used
 // to retrieve data. Use qfn to apply where filters (and other query modifiers).
+// If no row is found, a *NotFoundError (that wraps sql.ErrNoRows) is returned.
 func GetContext(ctx context.Context, dbtx sqlx.QueryerContext, dest interface{}, qfn func(rq squirrel.SelectBuilder) squirrel.SelectBuilder) error {
+	dbtx = Queryer(ctx, dbtx)
 	// 1 - extract ther underlying type
 	value := reflect.ValueOf(dest)
 	if isNilSafe(value) {
 		return errors.New("item is nil")
 	}
-	var rq squirrel.SelectBuilder
 	if isTypeSliceOrSlicePointer(value.Type()) {
 		return errors.New("GetContext: cannot use a slice or a slice pointer")
 	}
@@ -223,32 +291,31 @@ func GetContext(ctx context.Context, dbtx sqlx.QueryerContext, dest interface{},
 	if columnsResult.Err != nil {
 		return columnsResult.Err
 	}
-	rq = squirrel.Select(columnsResult.SelectColumns(ctx)...)
-	seltable := columnsResult.GetTableNameMeta(ctx)
-	if seltable == "" {
-		return errors.New("select table not found")
-	}
-	rq = rq.From(seltable)
-	if joins := columnsResult.SelectJoins(ctx); len(joins) > 0 {
-		jr := extractJoinReplace(ctx)
-		for _, v := range joins {
-			v = mapReplace(v, jr)
-			if strings.Contains(strings.ToUpper(v), "JOIN ") {
-				rq = rq.JoinClause(v)
-			} else {
-				rq = rq.Join(v)
-			}
-		}
+	rq, err := selectBuilder(ctx, dbtx, columnsResult)
+	if err != nil {
+		return err
 	}
 	if qfn != nil {
 		rq = qfn(rq)
 	}
+	rq = columnsResult.pruneJoins(ctx, rq)
 	q, args, err := rq.ToSql()
 	if err != nil {
 		return fmt.Errorf("failed to build query: %w", err)
 	}
-	if err := sqlx.GetContext(ctx, dbtx, dest, q, args...); err != nil {
-		return err
+	if columnsResult.protoScan {
+		err = getProto(ctx, dbtx, columnsResult, q, args...)
+	} else {
+		err = sqlx.GetContext(ctx, dbtx, dest, q, args...)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &NotFoundError{
+				Name: tableName(columnsResult.GetTableNameMeta(ctx)),
+				Err:  err,
+			}
+		}
+		return ClassifyError(err)
 	}
 	if err := remap(dest); err != nil {
 		return fmt.Errorf("failed to remap: %w", err)
@@ -259,6 +326,7 @@ func GetContext(ctx context.Context, dbtx sqlx.QueryerContext, dest interface{},
 // SelectContext executes a SelectColumnScan on dest (with reflection) to determine which table, columns and joins are used
 // to retrieve data. Use qfn to apply where filters (and other query modifiers).
 func SelectContext(ctx context.Context, dbtx sqlx.QueryerContext, dest interface{}, qfn func(rq squirrel.SelectBuilder) squirrel.SelectBuilder) error {
+	dbtx = Queryer(ctx, dbtx)
 	// 1 - extract ther underlying type
 	value := reflect.ValueOf(dest)
 	if err := errIfNotAPointerOrNil(value); err != nil {
@@ -280,36 +348,62 @@ func SelectContext(ctx context.Context, dbtx sqlx.QueryerContext, dest interface
 		return columnsResult.Err
 	}
 	// 2 - build query
-	rq := squirrel.Select(columnsResult.SelectColumns(ctx)...)
-	seltable := columnsResult.GetTableNameMeta(ctx)
-	if seltable == "" {
-		return errors.New("select table not found")
-	}
-	rq = rq.From(seltable)
-	if joins := columnsResult.SelectJoins(ctx); len(joins) > 0 {
-		jr := extractJoinReplace(ctx)
-		for _, v := range joins {
-			v = mapReplace(v, jr)
-			if strings.Contains(strings.ToUpper(v), "JOIN ") {
-				rq = rq.JoinClause(v)
-			} else {
-				rq = rq.Join(v)
-			}
-		}
+	rq, err := selectBuilder(ctx, dbtx, columnsResult)
+	if err != nil {
+		return err
 	}
 	if qfn != nil {
 		rq = qfn(rq)
 	}
+	rq = columnsResult.pruneJoins(ctx, rq)
 	q, args, err := rq.ToSql()
 	if err != nil {
 		return fmt.Errorf("failed to build query: %w", err)
 	}
 
-	if err := sqlx.SelectContext(ctx, dbtx, dest, q, args...); err != nil {
-		return err
+	if columnsResult.protoScan {
+		err = selectProto(ctx, dbtx, value, q, args...)
+	} else {
+		err = sqlx.SelectContext(ctx, dbtx, dest, q, args...)
+	}
+	if err != nil {
+		return ClassifyError(err)
 	}
 	if err := remap(dest); err != nil {
 		return fmt.Errorf("failed to remap: %w", err)
 	}
 	return nil
 }
+
+// selectBuilder builds the SELECT ... FROM ... JOIN ... of a SelectColumnScan result
+func selectBuilder(ctx context.Context, dbtx interface{}, columnsResult ColumnsResult) (squirrel.SelectBuilder, error) {
+	rq := DialectOf(ctx, dbtx).Builder().Select(columnsResult.SelectColumns(ctx)...)
+	seltable := columnsResult.GetTableNameMeta(ctx)
+	if seltable == "" {
+		return rq, errors.New("select table not found")
+	}
+	rq = rq.From(seltable)
+	if filter := softDeleteFilter(ctx, columnsResult); filter != nil {
+		rq = rq.Where(filter)
+	}
+	joins := columnsResult.selectJoins(ctx)
+	clauses := make([]string, len(joins))
+	jr := extractJoinReplace(ctx)
+	for i, j := range joins {
+		clauses[i] = mapReplace(j.clause, jr)
+	}
+	// the joins that are not needed by the read mask are dropped by pruneJoins (after qfn)
+	return addJoins(rq, clauses), nil
+}
+
+// addJoins adds the join clauses to rq
+func addJoins(rq squirrel.SelectBuilder, joins []string) squirrel.SelectBuilder {
+	for _, v := range joins {
+		if strings.Contains(strings.ToUpper(v), "JOIN ") {
+			rq = rq.JoinClause(v)
+		} else {
+			rq = rq.Join(v)
+		}
+	}
+	return rq
+}
@@ -2,6 +2,8 @@ package protodb_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
@@ -132,3 +134,23 @@ func TestGetContext(t *testing.T) {
 	require.Equal(t, int(1), item.ID)
 	require.Equal(t, "Alice", item.Name)
 }
+
+func TestGetContextNotFound(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	item := struct {
+		ID   int    `db:"id,table=agents a,select=a.id"`
+		Name string `db:"name"`
+	}{}
+
+	mock.ExpectQuery("SELECT").WithArgs(1).WillReturnRows(mock.NewRows([]string{"id", "name"}))
+	err := protodb.GetContext(context.Background(), db, &item, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("a.id=?", 1)
+	})
+	require.Error(t, err)
+	require.True(t, protodb.IsNotFound(err))
+	require.True(t, errors.Is(err, sql.ErrNoRows))
+	require.Equal(t, "agents: not found", err.Error())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
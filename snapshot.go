@@ -0,0 +1,196 @@
+package protodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	"google.golang.org/protobuf/proto"
+)
+
+// Snapshot is a copy of the column values of an item (see TakeSnapshot and UpdateChangedContext)
+type Snapshot struct {
+	typ    reflect.Type
+	values map[string]interface{}
+}
+
+// TakeSnapshot copies the column values (UpdateColumnScan) of item, to be compared by UpdateChangedContext
+// after the item is modified.
+func TakeSnapshot(item interface{}) (*Snapshot, error) {
+	value := reflect.ValueOf(item)
+	if isNilSafe(value) {
+		return nil, errors.New("item is nil")
+	}
+	columns := UpdateColumnScan(value)
+	if err := columns.Err; err != nil {
+		return nil, err
+	}
+	snap := &Snapshot{
+		typ:    reflectx.Deref(value.Type()),
+		values: make(map[string]interface{}, len(columns.Columns)),
+	}
+	for _, v := range columns.Columns {
+		if v.Name == "-" || v.Name == "" {
+			continue
+		}
+		snap.values[v.Name] = snapshotValue(v.FieldValue)
+	}
+	return snap, nil
+}
+
+// Changed returns the columns of item whose values differ from the snapshot (the version, autocreate
+// and autoupdate columns are ignored). The columns of a nested struct that is nil in item (but not in
+// the snapshot) are changed to NULL.
+func (s *Snapshot) Changed(item interface{}) ([]string, error) {
+	changed, _, err := s.changes(item)
+	return changed, err
+}
+
+// changes returns the changed columns of item and the ones (also included in changed) that are
+// changed to NULL because they are inside a nil nested struct
+func (s *Snapshot) changes(item interface{}) ([]string, []string, error) {
+	value := reflect.ValueOf(item)
+	if isNilSafe(value) {
+		return nil, nil, errors.New("item is nil")
+	}
+	if t := reflectx.Deref(value.Type()); t != s.typ {
+		return nil, nil, fmt.Errorf("snapshot of %v cannot be compared with %v", s.typ, t)
+	}
+	columns := UpdateColumnScan(value)
+	if err := columns.Err; err != nil {
+		return nil, nil, err
+	}
+	changed := make([]string, 0)
+	scanned := make(map[string]struct{}, len(columns.Columns))
+	for _, v := range columns.Columns {
+		if v.Name == "-" || v.Name == "" || v.MetaFlag("version") || isAutoTime(v, "autocreate", "autoupdate") {
+			continue
+		}
+		scanned[v.Name] = struct{}{}
+		if !snapshotEqual(s.values[v.Name], v.FieldValue.Interface()) {
+			changed = append(changed, v.Name)
+		}
+	}
+	nullColumns := make([]string, 0)
+	if columns.protoScan {
+		return changed, nullColumns, nil
+	}
+	// the columns inside nil nested structs are not scanned by UpdateColumnScan
+	for _, f := range cachedTypeMeta(s.typ, map[string]string{"db": ","}, updateScanTags) {
+		v := TagData{Name: f.name, Meta: f.meta}
+		if v.Name == "-" || v.Name == "" || v.MetaFlag("version") || isAutoTime(v, "autocreate", "autoupdate") {
+			continue
+		}
+		if _, ok := scanned[v.Name]; ok {
+			continue
+		}
+		if old, ok := s.values[v.Name]; !ok || isNilSafe(reflect.ValueOf(old)) {
+			continue
+		}
+		scanned[v.Name] = struct{}{}
+		nullColumns = append(nullColumns, v.Name)
+	}
+	return append(changed, nullColumns...), nullColumns, nil
+}
+
+// snapshotValue copies the value of a field (pointers, byte slices and protobuf messages are copied,
+// so changes made to the item do not change the snapshot)
+func snapshotValue(fv reflect.Value) interface{} {
+	if m, ok := fv.Interface().(proto.Message); ok && !isNilSafe(fv) {
+		return proto.Clone(m)
+	}
+	switch fv.Kind() {
+	case reflect.Ptr:
+		if fv.IsNil() {
+			return fv.Interface()
+		}
+		cp := reflect.New(fv.Type().Elem())
+		cp.Elem().Set(fv.Elem())
+		return cp.Interface()
+	case reflect.Slice:
+		if fv.IsNil() {
+			return fv.Interface()
+		}
+		cp := reflect.MakeSlice(fv.Type(), fv.Len(), fv.Len())
+		reflect.Copy(cp, fv)
+		return cp.Interface()
+	case reflect.Map:
+		if fv.IsNil() {
+			return fv.Interface()
+		}
+		cp := reflect.MakeMapWithSize(fv.Type(), fv.Len())
+		iter := fv.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return cp.Interface()
+	}
+	return fv.Interface()
+}
+
+func snapshotEqual(a, b interface{}) bool {
+	if ma, ok := a.(proto.Message); ok {
+		if mb, ok := b.(proto.Message); ok {
+			return proto.Equal(ma, mb)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// UpdateChangedContext updates only the columns of modified whose values differ from original, which
+// can be a *Snapshot (see TakeSnapshot) or an unmodified copy of the item. Unlike UpdateContext, the
+// changed columns are always set (skipnil and skipzero are ignored), so a column can be set to zero,
+// and the columns of a nested struct that became nil are set to NULL.
+// Use qfn to apply where filters (and other query modifiers).
+// The changed columns are returned (e.g. for audit logs). If no column changed, no query is executed
+// and the result is nil.
+// Example:
+//      snap, _ := protodb.TakeSnapshot(order)
+//      order.Status = ""
+//      order.Total = 0
+//      // UPDATE orders SET status = ?, total = ? WHERE id = ?
+//      changed, _, err := protodb.UpdateChangedContext(ctx, db, snap, order, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+//          return rq.Where("id = ?", order.ID)
+//      })
+func UpdateChangedContext(ctx context.Context, dbtx sqlx.ExecerContext, original, modified interface{}, qfn func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder) ([]string, sql.Result, error) {
+	snap, ok := original.(*Snapshot)
+	if !ok {
+		var err error
+		if snap, err = TakeSnapshot(original); err != nil {
+			return nil, nil, err
+		}
+	}
+	changed, nullColumns, err := snap.changes(modified)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changed) == 0 {
+		return changed, nil, nil
+	}
+	changedMap := make(map[string]struct{}, len(changed))
+	for _, name := range changed {
+		changedMap[name] = struct{}{}
+	}
+	dialect := DialectOf(ctx, Execer(ctx, dbtx))
+	result, err := updateContext(ctx, dbtx, modified, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		for _, name := range nullColumns {
+			rq = rq.Set(dialect.column(name), nil)
+		}
+		if qfn != nil {
+			rq = qfn(rq)
+		}
+		return rq
+	}, nil, func(v TagData) bool {
+		_, ok := changedMap[v.Name]
+		return ok
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return changed, result, nil
+}
@@ -0,0 +1,107 @@
+package protodb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type snapOrder struct {
+	ID       int                    `db:"id,table=orders"`
+	Status   string                 `db:"status,skipzero=true"`
+	Total    int                    `db:"total"`
+	Note     *string                `db:"note"`
+	PaidAt   *timestamppb.Timestamp `db:"paid_at"`
+	Tags     []byte                 `db:"tags"`
+	Version  int64                  `db:"version,version"`
+	Modified time.Time              `db:"modified_at,autoupdate"`
+}
+
+func TestUpdateChangedContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	t0 := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	ctx := protodb.WithClock(context.Background(), func() time.Time { return t0 })
+	note := "a"
+	order := &snapOrder{ID: 1, Status: "paid", Total: 10, Note: &note, PaidAt: timestamppb.New(t0), Tags: []byte("x"), Version: 1}
+	qfn := func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", order.ID)
+	}
+
+	snap, err := protodb.TakeSnapshot(order)
+	require.NoError(t, err)
+
+	// nothing changed: no query
+	changed, result, err := protodb.UpdateChangedContext(ctx, db, snap, order, qfn)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+	require.Nil(t, result)
+
+	// changes made through pointers and slices are detected; zero values are set
+	order.Status = ""
+	*order.Note = "b"
+	order.Tags[0] = 'y'
+	mock.ExpectExec("UPDATE orders SET status = \\?, note = \\?, tags = \\?, version = version \\+ 1, modified_at = \\? WHERE id = \\? AND version = \\?").
+		WithArgs("", "b", []byte("y"), t0, 1, int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	changed, _, err = protodb.UpdateChangedContext(ctx, db, snap, order, qfn)
+	require.NoError(t, err)
+	require.Equal(t, []string{"status", "note", "tags"}, changed)
+	require.Equal(t, int64(2), order.Version)
+
+	// the original can be a copy of the item
+	original := *order
+	modified := *order
+	modified.PaidAt = nil
+	mock.ExpectExec("UPDATE orders SET paid_at = \\?, version = version \\+ 1, modified_at = \\? WHERE id = \\? AND version = \\?").
+		WithArgs(nil, t0, 1, int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	changed, _, err = protodb.UpdateChangedContext(ctx, db, &original, &modified, qfn)
+	require.NoError(t, err)
+	require.Equal(t, []string{"paid_at"}, changed)
+
+	_, _, err = protodb.UpdateChangedContext(ctx, db, snap, &struct {
+		ID int `db:"id,table=orders"`
+	}{}, qfn)
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+type snapAddress struct {
+	Street string  `db:"address_street"`
+	City   *string `db:"address_city"`
+}
+
+type snapCustomer struct {
+	ID      int          `db:"id,table=customers"`
+	Name    string       `db:"name"`
+	Address *snapAddress `db:"-"`
+}
+
+func TestUpdateChangedContextNilNested(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+	customer := &snapCustomer{ID: 1, Name: "Ana", Address: &snapAddress{Street: "Main St"}}
+	snap, err := protodb.TakeSnapshot(customer)
+	require.NoError(t, err)
+
+	// the columns of the nested struct that became nil are set to NULL (the nil city was already NULL)
+	customer.Address = nil
+	mock.ExpectExec("^"+regexp.QuoteMeta("UPDATE customers SET address_street = ? WHERE id = ?")+"$").
+		WithArgs(nil, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	changed, _, err := protodb.UpdateChangedContext(ctx, db, snap, customer, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", customer.ID)
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"address_street"}, changed)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,143 @@
+package protodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/lann/builder"
+)
+
+// WithDeleted makes GetContext, SelectContext and CountContext (and the functions built on them)
+// return the soft deleted rows (see the "softdelete" subtag).
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeleted, true)
+}
+
+// WithHardDelete makes DeleteContext run a DELETE even if the item has a "softdelete" column.
+func WithHardDelete(ctx context.Context) context.Context {
+	return context.WithValue(ctx, hardDelete, true)
+}
+
+// softDeleteColumn returns the column of the root table flagged with the "softdelete" subtag
+func (r ColumnsResult) softDeleteColumn() (TagData, bool) {
+	for _, v := range r.Columns {
+		if v.Name != "-" && v.Name != "" && v.MetaFlag("softdelete") && r.rootColumn(v) {
+			return v, true
+		}
+	}
+	return TagData{}, false
+}
+
+// rootColumn returns true if v is a column of the root table: a field of the scanned struct (or of
+// its embedded structs) without a join. The columns of nested structs usually belong to joined tables.
+func (r ColumnsResult) rootColumn(v TagData) bool {
+	if v.MetaString("join", "") != "" {
+		return false
+	}
+	if r.typ == nil {
+		return len(v.FieldIndex) <= 1
+	}
+	t := r.typ
+	for _, i := range v.FieldIndex[:len(v.FieldIndex)-1] {
+		sf := reflectx.Deref(t).Field(i)
+		if !sf.Anonymous {
+			return false
+		}
+		t = sf.Type
+	}
+	return true
+}
+
+// softDeleteFilter returns the "deleted_at IS NULL" filter of the "softdelete" column of the root table
+// (nil if ctx is created with WithDeleted). The soft deleted rows of joined tables are not filtered,
+// since a parent row must not be hidden by its children: filter them in the ON clause of the join.
+func softDeleteFilter(ctx context.Context, columnsResult ColumnsResult) squirrel.Sqlizer {
+	if contextFlag(ctx, includeDeleted) {
+		return nil
+	}
+	v, ok := columnsResult.softDeleteColumn()
+	if !ok || (v.RecursiveIf != nil && !contextIfIsTrue(ctx, *v.RecursiveIf, true)) {
+		return nil
+	}
+	return squirrel.Eq{selectExpr(v): nil}
+}
+
+// softDeleteBuilder converts the DELETE of a soft deletable item into an UPDATE that sets the
+// softdelete column (only the rows that are not deleted yet are updated). The WHERE, ORDER BY and
+// LIMIT of the delete are preserved.
+func softDeleteBuilder(dialect Dialect, tname string, col TagData, value interface{}, rq squirrel.DeleteBuilder) squirrel.UpdateBuilder {
+	uq := dialect.Builder().Update(tname).Set(dialect.column(col.Name), value)
+	if parts, ok := builder.Get(rq, "WhereParts"); ok {
+		uq = builder.Extend(uq, "WhereParts", parts).(squirrel.UpdateBuilder)
+	}
+	if orderBys, ok := builder.Get(rq, "OrderBys"); ok {
+		uq = builder.Extend(uq, "OrderBys", orderBys).(squirrel.UpdateBuilder)
+	}
+	if limit, ok := builder.Get(rq, "Limit"); ok {
+		uq = builder.Set(uq, "Limit", limit).(squirrel.UpdateBuilder)
+	}
+	return uq.Where(squirrel.Eq{dialect.column(col.Name): nil})
+}
+
+// RestoreContext clears the "softdelete" column of a soft deleted item (see DeleteContext).
+// Use qfn to apply where filters (and other query modifiers). If qfn is nil, the columns tagged
+// with "key" (or "pk") are used to filter the rows to be restored.
+// Example:
+//      type Example struct {
+//         ID        int        `db:"id,table=agents,key"`
+//         DeletedAt *time.Time `db:"deleted_at,softdelete"`
+//      }
+//      // UPDATE agents SET deleted_at = NULL WHERE id = ?
+//      protodb.RestoreContext(ctx, db, &Example{ID: 1}, nil)
+func RestoreContext(ctx context.Context, dbtx sqlx.ExecerContext, item interface{}, qfn func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder) (sql.Result, error) {
+	dbtx = Execer(ctx, dbtx)
+	value := reflect.ValueOf(item)
+	if isNilSafe(value) {
+		return nil, errors.New("item is nil")
+	}
+	if isTypeSliceOrSlicePointer(value.Type()) {
+		return nil, errors.New("RestoreContext: cannot restore a slice or a slice pointer")
+	}
+	columns := DeleteColumnScan(value)
+	if err := columns.Err; err != nil {
+		return nil, err
+	}
+	tname := columns.GetTableNameMeta(ctx)
+	if tname == "" {
+		return nil, errors.New("(restore) subtag 'table' not found")
+	}
+	col, ok := columns.softDeleteColumn()
+	if !ok {
+		return nil, errors.New("(restore) subtag 'softdelete' not found")
+	}
+	dialect := DialectOf(ctx, dbtx)
+	rq := dialect.Builder().Update(tname).Set(dialect.column(col.Name), nil)
+	if qfn != nil {
+		rq = qfn(rq)
+	} else {
+		for _, v := range columns.Columns {
+			if v.Name != "-" && v.Name != "" && v.IsKey() {
+				rq = rq.Where(squirrel.Eq{dialect.column(v.Name): resolveValue(v)})
+			}
+		}
+	}
+	if !builderHasParts(rq, "WhereParts") && !contextFlag(ctx, allowUnfilteredDelete) {
+		return nil, errors.New("(restore) refusing to restore without a WHERE clause (see WithUnfilteredDelete)")
+	}
+	rawq, args, err := rq.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	result, err := execContext(ctx, dbtx, rawq, args...)
+	if err != nil {
+		return nil, err
+	}
+	setTimeValue(col.FieldValue, time.Time{})
+	return result, nil
+}
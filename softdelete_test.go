@@ -0,0 +1,122 @@
+package protodb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Masterminds/squirrel"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+type softAgent struct {
+	ID        int        `db:"id,table=agents,key"`
+	Name      string     `db:"name"`
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+func TestSoftDeleteSelect(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT id, name, deleted_at FROM agents WHERE deleted_at IS NULL AND id = \\?").
+		WithArgs(1).WillReturnRows(mock.NewRows([]string{"id", "name", "deleted_at"}).AddRow(1, "a", nil))
+	item := &softAgent{}
+	require.NoError(t, protodb.GetContext(ctx, db, item, func(rq squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return rq.Where("id = ?", 1)
+	}))
+
+	mock.ExpectQuery("SELECT id, name, deleted_at FROM agents$").
+		WillReturnRows(mock.NewRows([]string{"id", "name", "deleted_at"}).AddRow(1, "a", nil).AddRow(2, "b", time.Now()))
+	items := make([]*softAgent, 0)
+	require.NoError(t, protodb.SelectContext(protodb.WithDeleted(ctx), db, &items, nil))
+	require.Len(t, items, 2)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM agents WHERE deleted_at IS NULL$").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+	total, err := protodb.CountContext(ctx, db, &items, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSoftDeleteContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	item := &softAgent{ID: 7, Name: "Mole Person"}
+	mock.ExpectExec("UPDATE agents SET deleted_at = \\? WHERE id = \\? AND deleted_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.DeleteContext(ctx, db, item, nil)
+	require.NoError(t, err)
+	require.NotNil(t, item.DeletedAt)
+
+	// the where, order by and limit of qfn are kept
+	mock.ExpectExec("UPDATE agents SET deleted_at = \\? WHERE name = \\? AND deleted_at IS NULL ORDER BY id LIMIT 2").
+		WithArgs(sqlmock.AnyArg(), "Mole Person").WillReturnResult(sqlmock.NewResult(0, 2))
+	_, err = protodb.DeleteContext(ctx, db, item, func(rq squirrel.DeleteBuilder) squirrel.DeleteBuilder {
+		return rq.Where("name = ?", item.Name).OrderBy("id").Limit(2)
+	})
+	require.NoError(t, err)
+
+	mock.ExpectExec("UPDATE agents SET deleted_at = \\? WHERE id = \\?").
+		WithArgs(nil, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = protodb.RestoreContext(ctx, db, item, nil)
+	require.NoError(t, err)
+	require.Nil(t, item.DeletedAt)
+
+	mock.ExpectExec("DELETE FROM agents WHERE id = \\?").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = protodb.DeleteContext(protodb.WithHardDelete(ctx), db, item, nil)
+	require.NoError(t, err)
+
+	// restore requires a softdelete column
+	_, err = protodb.RestoreContext(ctx, db, &struct {
+		ID int `db:"id,table=agents,key"`
+	}{ID: 1}, nil)
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+type softChild struct {
+	ID        *int       `db:"child_id,select=c.id AS child_id,join=LEFT JOIN children c ON c.parent_id=p.id AND c.deleted_at IS NULL"`
+	DeletedAt *time.Time `db:"child_deleted_at,select=c.deleted_at AS child_deleted_at,softdelete"`
+}
+
+type softParent struct {
+	ID        int        `db:"id,table=parents p,select=p.id,key"`
+	DeletedAt *time.Time `db:"deleted_at,select=p.deleted_at,softdelete"`
+	Child     softChild
+}
+
+func TestSoftDeleteJoinedChild(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	// only the root table is filtered: a parent with a soft deleted child is still returned
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT p.id, p.deleted_at, c.id AS child_id, c.deleted_at AS child_deleted_at FROM parents p LEFT JOIN children c ON c.parent_id=p.id AND c.deleted_at IS NULL WHERE p.deleted_at IS NULL") + "$").
+		WillReturnRows(mock.NewRows([]string{"id", "deleted_at", "child.child_id", "child.child_deleted_at"}).
+			AddRow(1, nil, 10, nil).
+			AddRow(2, nil, nil, nil))
+	items := make([]*softParent, 0)
+	require.NoError(t, protodb.SelectContext(ctx, db, &items, nil))
+	require.Len(t, items, 2)
+
+	// the soft delete updates the root table column
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE parents p SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL")).
+		WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.DeleteContext(ctx, db, items[0], nil)
+	require.NoError(t, err)
+	require.NotNil(t, items[0].DeletedAt)
+	require.Nil(t, items[0].Child.DeletedAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
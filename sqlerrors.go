@@ -1,13 +1,42 @@
 package protodb
 
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// Sentinel errors to compare against with errors.Is. Every protodb error type matches its sentinel:
+//
+//	errors.Is(err, protodb.ErrNotFound) // true if err wraps a *NotFoundError
+var (
+	ErrQuery          = errors.New("query error")
+	ErrNotFound       = errors.New("not found")
+	ErrDuplicateKey   = errors.New("duplicate key")
+	ErrForeignKey     = errors.New("foreign key violation")
+	ErrCheckViolation = errors.New("check constraint violation")
+	ErrDeadlock       = errors.New("deadlock")
+	ErrLockTimeout    = errors.New("lock wait timeout")
+	ErrSerialization  = errors.New("serialization failure")
+	ErrStaleObject    = errors.New("stale object")
+)
+
 type QueryError struct {
 	Message string // public message
 	Query   string // query identifier
 	Err     error  // private underlying error
 }
 
-func (e *QueryError) Error() string { return e.Query + ": " + e.Err.Error() }
-func (e *QueryError) Unwrap() error { return e.Err }
+func (e *QueryError) Error() string {
+	if e.Err == nil {
+		return e.Query + ": " + e.Message
+	}
+	return e.Query + ": " + e.Err.Error()
+}
+func (e *QueryError) Unwrap() error        { return e.Err }
+func (e *QueryError) Is(target error) bool { return target == ErrQuery }
 
 func QueryErr(pubmsg, queryid string, err error) error {
 	return &QueryError{
@@ -17,32 +46,25 @@ func QueryErr(pubmsg, queryid string, err error) error {
 	}
 }
 
-// IsQueryError tests if an error is a *QueryError
+// IsQueryError tests if an error is (or wraps) a *QueryError
 func IsQueryError(err error) bool {
-	if err == nil {
-		return false
-	}
-	if _, ok := err.(*QueryError); ok {
-		return true
-	}
-	return false
+	var target *QueryError
+	return errors.As(err, &target)
 }
 
 type NotFoundError struct {
 	Name string
+	Err  error // underlying error (e.g. sql.ErrNoRows)
 }
 
-func (e *NotFoundError) Error() string { return e.Name + ": not found" }
+func (e *NotFoundError) Error() string        { return e.Name + ": not found" }
+func (e *NotFoundError) Unwrap() error        { return e.Err }
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
 
-// IsNotFound tests if an error is a *NotFoundError
+// IsNotFound tests if an error is (or wraps) a *NotFoundError
 func IsNotFound(err error) bool {
-	if err == nil {
-		return false
-	}
-	if _, ok := err.(*NotFoundError); ok {
-		return true
-	}
-	return false
+	var target *NotFoundError
+	return errors.As(err, &target)
 }
 
 func NotFound(name string) error {
@@ -50,3 +72,237 @@ func NotFound(name string) error {
 		Name: name,
 	}
 }
+
+// DuplicateKeyError is returned when an insert or update violates a unique (or primary) key
+type DuplicateKeyError struct {
+	Key string // the key or constraint name (if reported by the driver)
+	Err error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	if e.Key == "" {
+		return "duplicate key" + errSuffix(e.Err)
+	}
+	return "duplicate key " + e.Key + errSuffix(e.Err)
+}
+func (e *DuplicateKeyError) Unwrap() error        { return e.Err }
+func (e *DuplicateKeyError) Is(target error) bool { return target == ErrDuplicateKey }
+
+// ForeignKeyError is returned when a foreign key constraint fails (the referenced row does not
+// exist or the row is still referenced)
+type ForeignKeyError struct {
+	Constraint string // the constraint name (if reported by the driver)
+	Err        error
+}
+
+func (e *ForeignKeyError) Error() string        { return "foreign key violation" + errSuffix(e.Err) }
+func (e *ForeignKeyError) Unwrap() error        { return e.Err }
+func (e *ForeignKeyError) Is(target error) bool { return target == ErrForeignKey }
+
+// CheckViolationError is returned when a CHECK constraint fails
+type CheckViolationError struct {
+	Constraint string // the constraint name (if reported by the driver)
+	Err        error
+}
+
+func (e *CheckViolationError) Error() string        { return "check constraint violation" + errSuffix(e.Err) }
+func (e *CheckViolationError) Unwrap() error        { return e.Err }
+func (e *CheckViolationError) Is(target error) bool { return target == ErrCheckViolation }
+
+// DeadlockError is returned when the transaction was rolled back by the database to resolve a deadlock
+type DeadlockError struct {
+	Err error
+}
+
+func (e *DeadlockError) Error() string        { return "deadlock" + errSuffix(e.Err) }
+func (e *DeadlockError) Unwrap() error        { return e.Err }
+func (e *DeadlockError) Is(target error) bool { return target == ErrDeadlock }
+
+// LockTimeoutError is returned when a lock could not be acquired in time
+type LockTimeoutError struct {
+	Err error
+}
+
+func (e *LockTimeoutError) Error() string        { return "lock wait timeout" + errSuffix(e.Err) }
+func (e *LockTimeoutError) Unwrap() error        { return e.Err }
+func (e *LockTimeoutError) Is(target error) bool { return target == ErrLockTimeout }
+
+// SerializationError is returned when a serializable transaction could not be committed
+// because of concurrent updates (PostgreSQL 40001)
+type SerializationError struct {
+	Err error
+}
+
+func (e *SerializationError) Error() string        { return "serialization failure" + errSuffix(e.Err) }
+func (e *SerializationError) Unwrap() error        { return e.Err }
+func (e *SerializationError) Is(target error) bool { return target == ErrSerialization }
+
+// errSuffix returns ": " and the message of err ("" if err is nil)
+func errSuffix(err error) string {
+	if err == nil {
+		return ""
+	}
+	return ": " + err.Error()
+}
+
+// StaleObjectError is returned by UpdateContext when the row of an item with a "version" column
+// was changed (or deleted) after the item was loaded
+type StaleObjectError struct {
+	Name    string      // table name
+	Version interface{} // the version of the item
+}
+
+func (e *StaleObjectError) Error() string {
+	return fmt.Sprintf("%s: stale object (version %v)", e.Name, e.Version)
+}
+func (e *StaleObjectError) Is(target error) bool { return target == ErrStaleObject }
+
+// IsDuplicateKey tests if err is (or wraps) a *DuplicateKeyError
+func IsDuplicateKey(err error) bool {
+	var target *DuplicateKeyError
+	return errors.As(err, &target)
+}
+
+// IsForeignKey tests if err is (or wraps) a *ForeignKeyError
+func IsForeignKey(err error) bool {
+	var target *ForeignKeyError
+	return errors.As(err, &target)
+}
+
+// IsCheckViolation tests if err is (or wraps) a *CheckViolationError
+func IsCheckViolation(err error) bool {
+	var target *CheckViolationError
+	return errors.As(err, &target)
+}
+
+// IsDeadlock tests if err is (or wraps) a *DeadlockError
+func IsDeadlock(err error) bool {
+	var target *DeadlockError
+	return errors.As(err, &target)
+}
+
+// IsLockTimeout tests if err is (or wraps) a *LockTimeoutError
+func IsLockTimeout(err error) bool {
+	var target *LockTimeoutError
+	return errors.As(err, &target)
+}
+
+// IsSerializationFailure tests if err is (or wraps) a *SerializationError
+func IsSerializationFailure(err error) bool {
+	var target *SerializationError
+	return errors.As(err, &target)
+}
+
+// IsStaleObject tests if err is (or wraps) a *StaleObjectError
+func IsStaleObject(err error) bool {
+	var target *StaleObjectError
+	return errors.As(err, &target)
+}
+
+// ClassifyError converts a MySQL or PostgreSQL driver error into a typed protodb error
+// (*DuplicateKeyError, *ForeignKeyError, *CheckViolationError, *DeadlockError, *LockTimeoutError
+// or *SerializationError) that wraps err. Other errors are returned unchanged.
+//
+// The drivers are not imported: MySQL errors are recognized by the error number (the Number field
+// of github.com/go-sql-driver/mysql.MySQLError or the "Error 1062" message prefix) and PostgreSQL
+// errors by the SQLSTATE code (lib/pq, pgx).
+func ClassifyError(err error) error {
+	if err == nil || isClassified(err) {
+		return err
+	}
+	de := inspectDriverError(err)
+	switch de.mysqlNumber {
+	case 1062, 1586, 1022:
+		return &DuplicateKeyError{Key: de.constraint, Err: err}
+	case 1451, 1452, 1216, 1217:
+		return &ForeignKeyError{Constraint: de.constraint, Err: err}
+	case 3819:
+		return &CheckViolationError{Constraint: de.constraint, Err: err}
+	case 1213:
+		return &DeadlockError{Err: err}
+	case 1205:
+		return &LockTimeoutError{Err: err}
+	}
+	switch de.sqlstate {
+	case "23505":
+		return &DuplicateKeyError{Key: de.constraint, Err: err}
+	case "23503":
+		return &ForeignKeyError{Constraint: de.constraint, Err: err}
+	case "23514":
+		return &CheckViolationError{Constraint: de.constraint, Err: err}
+	case "40P01":
+		return &DeadlockError{Err: err}
+	case "55P03":
+		return &LockTimeoutError{Err: err}
+	case "40001":
+		return &SerializationError{Err: err}
+	}
+	return err
+}
+
+// isClassified returns true if err is already a typed protodb error
+func isClassified(err error) bool {
+	return IsDuplicateKey(err) || IsForeignKey(err) || IsCheckViolation(err) ||
+		IsDeadlock(err) || IsLockTimeout(err) || IsSerializationFailure(err)
+}
+
+// driverError is the information extracted from a driver error
+type driverError struct {
+	mysqlNumber int
+	sqlstate    string
+	constraint  string
+}
+
+var (
+	mysqlErrorRe      = regexp.MustCompile(`^Error (\d{4,5})(?: \(([0-9A-Z]{5})\))?:`)
+	mysqlDuplicateKey = regexp.MustCompile(`for key '([^']+)'`)
+	mysqlConstraint   = regexp.MustCompile("CONSTRAINT [`'\"]([^`'\"]+)[`'\"]")
+)
+
+func inspectDriverError(err error) driverError {
+	de := driverError{}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if st, ok := e.(interface{ SQLState() string }); ok && de.sqlstate == "" {
+			de.sqlstate = st.SQLState()
+		}
+		rv := reflect.Indirect(reflect.ValueOf(e))
+		if rv.Kind() == reflect.Struct {
+			if f := rv.FieldByName("Number"); f.IsValid() {
+				switch f.Kind() {
+				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+					de.mysqlNumber = int(f.Uint())
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+					de.mysqlNumber = int(f.Int())
+				}
+			}
+			if f := rv.FieldByName("Code"); f.IsValid() && f.Kind() == reflect.String && len(f.String()) == 5 && de.sqlstate == "" {
+				de.sqlstate = f.String()
+			}
+			for _, name := range []string{"ConstraintName", "Constraint"} {
+				if f := rv.FieldByName(name); f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+					de.constraint = f.String()
+				}
+			}
+		}
+		if de.mysqlNumber == 0 {
+			if m := mysqlErrorRe.FindStringSubmatch(e.Error()); m != nil {
+				de.mysqlNumber, _ = strconv.Atoi(m[1])
+				if m[2] != "" && de.sqlstate == "" {
+					de.sqlstate = m[2]
+				}
+			}
+		}
+		if de.mysqlNumber != 0 || de.sqlstate != "" {
+			break
+		}
+	}
+	if de.mysqlNumber != 0 && de.constraint == "" {
+		msg := err.Error()
+		if m := mysqlDuplicateKey.FindStringSubmatch(msg); m != nil {
+			de.constraint = m[1]
+		} else if m := mysqlConstraint.FindStringSubmatch(msg); m != nil {
+			de.constraint = m[1]
+		}
+	}
+	return de
+}
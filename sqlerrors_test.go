@@ -0,0 +1,141 @@
+package protodb_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mysqlError has the same shape as github.com/go-sql-driver/mysql.MySQLError
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+// pgError has the same shape as github.com/jackc/pgconn.PgError
+type pgError struct {
+	Code           string
+	Message        string
+	ConstraintName string
+}
+
+func (e *pgError) Error() string    { return "ERROR: " + e.Message + " (SQLSTATE " + e.Code + ")" }
+func (e *pgError) SQLState() string { return e.Code }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		check func(error) bool
+	}{
+		{"mysql duplicate", &mysqlError{1062, "Duplicate entry 'a@b.c' for key 'users.email'"}, protodb.IsDuplicateKey},
+		{"mysql duplicate message", errors.New("Error 1062 (23000): Duplicate entry '1' for key 'PRIMARY'"), protodb.IsDuplicateKey},
+		{"mysql fk parent", &mysqlError{1451, "Cannot delete or update a parent row"}, protodb.IsForeignKey},
+		{"mysql fk child", &mysqlError{1452, "Cannot add or update a child row"}, protodb.IsForeignKey},
+		{"mysql deadlock", &mysqlError{1213, "Deadlock found when trying to get lock"}, protodb.IsDeadlock},
+		{"mysql lock timeout", &mysqlError{1205, "Lock wait timeout exceeded"}, protodb.IsLockTimeout},
+		{"mysql check", &mysqlError{3819, "Check constraint 'c1' is violated."}, protodb.IsCheckViolation},
+		{"pg duplicate", &pgError{Code: "23505", ConstraintName: "users_email_key"}, protodb.IsDuplicateKey},
+		{"pg fk", &pgError{Code: "23503"}, protodb.IsForeignKey},
+		{"pg check", &pgError{Code: "23514"}, protodb.IsCheckViolation},
+		{"pg deadlock", &pgError{Code: "40P01"}, protodb.IsDeadlock},
+		{"pg lock timeout", &pgError{Code: "55P03"}, protodb.IsLockTimeout},
+		{"pg serialization", &pgError{Code: "40001"}, protodb.IsSerializationFailure},
+		{"wrapped", fmt.Errorf("insert user: %w", &mysqlError{1062, "Duplicate entry"}), protodb.IsDuplicateKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := protodb.ClassifyError(tt.err)
+			assert.True(t, tt.check(err))
+			assert.True(t, errors.Is(err, tt.err))
+			// classifying twice does not wrap again
+			assert.Equal(t, err, protodb.ClassifyError(err))
+		})
+	}
+
+	plain := errors.New("boom")
+	assert.Equal(t, plain, protodb.ClassifyError(plain))
+	assert.Nil(t, protodb.ClassifyError(nil))
+
+	var dk *protodb.DuplicateKeyError
+	require.True(t, errors.As(protodb.ClassifyError(&mysqlError{1062, "Duplicate entry 'a@b.c' for key 'users.email'"}), &dk))
+	assert.Equal(t, "users.email", dk.Key)
+	require.True(t, errors.As(protodb.ClassifyError(&pgError{Code: "23505", ConstraintName: "users_email_key"}), &dk))
+	assert.Equal(t, "users_email_key", dk.Key)
+}
+
+func TestInsertContextDuplicateKey(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	item := &struct {
+		Email string `db:"email,table=users"`
+	}{"a@b.c"}
+	mock.ExpectExec("INSERT INTO users").WillReturnError(&mysqlError{1062, "Duplicate entry 'a@b.c' for key 'users.email'"})
+	_, err := protodb.InsertContext(context.Background(), db, item, nil)
+	require.Error(t, err)
+	require.True(t, protodb.IsDuplicateKey(err))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestErrorHelpersWrapped(t *testing.T) {
+	wrap := func(err error, levels int) error {
+		for i := 0; i < levels; i++ {
+			err = fmt.Errorf("level %d: %w", i, err)
+		}
+		return err
+	}
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+		check    func(error) bool
+	}{
+		{"not found", &protodb.NotFoundError{Name: "agents", Err: sql.ErrNoRows}, protodb.ErrNotFound, protodb.IsNotFound},
+		{"query", protodb.QueryErr("failed to list", "list_agents", errors.New("boom")), protodb.ErrQuery, protodb.IsQueryError},
+		{"duplicate key", &protodb.DuplicateKeyError{Key: "email", Err: errors.New("dup")}, protodb.ErrDuplicateKey, protodb.IsDuplicateKey},
+		{"foreign key", &protodb.ForeignKeyError{Err: errors.New("fk")}, protodb.ErrForeignKey, protodb.IsForeignKey},
+		{"check", &protodb.CheckViolationError{Err: errors.New("check")}, protodb.ErrCheckViolation, protodb.IsCheckViolation},
+		{"deadlock", &protodb.DeadlockError{Err: errors.New("deadlock")}, protodb.ErrDeadlock, protodb.IsDeadlock},
+		{"lock timeout", &protodb.LockTimeoutError{Err: errors.New("timeout")}, protodb.ErrLockTimeout, protodb.IsLockTimeout},
+		{"serialization", &protodb.SerializationError{Err: errors.New("40001")}, protodb.ErrSerialization, protodb.IsSerializationFailure},
+	}
+	for _, tt := range tests {
+		for levels := 0; levels < 4; levels++ {
+			t.Run(fmt.Sprintf("%s/%d", tt.name, levels), func(t *testing.T) {
+				err := wrap(tt.err, levels)
+				assert.True(t, tt.check(err))
+				assert.True(t, errors.Is(err, tt.sentinel))
+				assert.False(t, tt.check(wrap(errors.New("other"), levels)))
+			})
+		}
+	}
+
+	err := wrap(&protodb.NotFoundError{Name: "agents", Err: sql.ErrNoRows}, 2)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	var nfe *protodb.NotFoundError
+	require.True(t, errors.As(err, &nfe))
+	assert.Equal(t, "agents", nfe.Name)
+	assert.False(t, protodb.IsNotFound(nil))
+	assert.False(t, errors.Is(protodb.NotFound("x"), protodb.ErrQuery))
+}
+
+func TestErrorNilErr(t *testing.T) {
+	assert.Equal(t, "duplicate key", (&protodb.DuplicateKeyError{}).Error())
+	assert.Equal(t, "duplicate key email", (&protodb.DuplicateKeyError{Key: "email"}).Error())
+	assert.Equal(t, "duplicate key email: dup", (&protodb.DuplicateKeyError{Key: "email", Err: errors.New("dup")}).Error())
+	assert.Equal(t, "foreign key violation", (&protodb.ForeignKeyError{}).Error())
+	assert.Equal(t, "check constraint violation", (&protodb.CheckViolationError{}).Error())
+	assert.Equal(t, "deadlock", (&protodb.DeadlockError{}).Error())
+	assert.Equal(t, "lock wait timeout", (&protodb.LockTimeoutError{}).Error())
+	assert.Equal(t, "serialization failure", (&protodb.SerializationError{}).Error())
+}
@@ -15,3 +15,11 @@ func MockDBMySQL(t *testing.T) (db *sqlx.DB, mock sqlm.Sqlmock) {
 	db = sqlx.NewDb(rawdb, "mysql")
 	return db, mock
 }
+
+// MockDBPostgres returns a "postgres" mock database
+func MockDBPostgres(t *testing.T) (db *sqlx.DB, mock sqlm.Sqlmock) {
+	rawdb, mock, err := sqlm.New()
+	require.NoError(t, err)
+	db = sqlx.NewDb(rawdb, "postgres")
+	return db, mock
+}
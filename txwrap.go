@@ -1,17 +1,259 @@
 package protodb
 
-import "github.com/jmoiron/sqlx"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
 
 // Wrap creates a new DB transaction that automatically commits or performs a rollback
 // when the function returns.
 func Wrap(db *sqlx.DB, fn func(*sqlx.Tx) error) error {
-	tx, err := db.Beginx()
+	return WrapContext(context.Background(), db, nil, func(_ context.Context, tx *sqlx.Tx) error {
+		return fn(tx)
+	})
+}
+
+// txState is the transaction stored in the context by WrapContext or WithTx
+type txState struct {
+	tx         *sqlx.Tx
+	mu         sync.Mutex
+	savepoints int        // number of savepoints created (used to name them)
+	hooks      []*txHooks // hooks of the transaction (hooks[0]) and of each open savepoint
+}
+
+// txHooks are the functions registered by AfterCommit and AfterRollback
+type txHooks struct {
+	afterCommit   []func()
+	afterRollback []func()
+}
+
+func newTxState(tx *sqlx.Tx) *txState {
+	return &txState{
+		tx:    tx,
+		hooks: []*txHooks{{}},
+	}
+}
+
+func txStateFromContext(ctx context.Context) *txState {
+	st, _ := ctx.Value(txKey).(*txState)
+	return st
+}
+
+func (st *txState) top() *txHooks {
+	return st.hooks[len(st.hooks)-1]
+}
+
+// beginSavepoint returns the name of a new savepoint and opens its hooks level
+func (st *txState) beginSavepoint() string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.savepoints++
+	st.hooks = append(st.hooks, &txHooks{})
+	return fmt.Sprintf("protodb_sp_%d", st.savepoints)
+}
+
+// endSavepoint closes the current hooks level. If released, its hooks are moved to the parent level;
+// otherwise (ROLLBACK TO SAVEPOINT) the after rollback hooks run and the after commit hooks are discarded.
+func (st *txState) endSavepoint(released bool) {
+	st.mu.Lock()
+	h := st.top()
+	st.hooks = st.hooks[:len(st.hooks)-1]
+	if released {
+		parent := st.top()
+		parent.afterCommit = append(parent.afterCommit, h.afterCommit...)
+		parent.afterRollback = append(parent.afterRollback, h.afterRollback...)
+		st.mu.Unlock()
+		return
+	}
+	st.mu.Unlock()
+	runHooks(h.afterRollback)
+}
+
+// done runs the hooks after the transaction is committed or rolled back
+func (st *txState) done(committed bool) {
+	st.mu.Lock()
+	hooks := st.hooks
+	st.hooks = []*txHooks{{}}
+	st.mu.Unlock()
+	for _, h := range hooks {
+		if committed {
+			runHooks(h.afterCommit)
+		} else {
+			runHooks(h.afterRollback)
+		}
+	}
+}
+
+func runHooks(fns []func()) {
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// WithTx stores tx in the context. The CRUD functions (GetContext, SelectContext, InsertContext,
+// UpdateContext...) executed with this context use tx instead of the dbtx parameter, and WrapContext
+// nests a savepoint instead of creating a new transaction.
+// The AfterCommit and AfterRollback hooks of a transaction stored with WithTx only run if it is
+// committed or rolled back with CommitContext or RollbackContext.
+func WithTx(ctx context.Context, tx *sqlx.Tx) context.Context {
+	if st := txStateFromContext(ctx); st != nil && st.tx == tx {
+		return ctx
+	}
+	return context.WithValue(ctx, txKey, newTxState(tx))
+}
+
+// TxFromContext returns the transaction stored in the context by WithTx or WrapContext
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	if st := txStateFromContext(ctx); st != nil {
+		return st.tx, true
+	}
+	return nil, false
+}
+
+// Execer returns the transaction stored in the context (see WithTx) or dbtx if there is none
+func Execer(ctx context.Context, dbtx sqlx.ExecerContext) sqlx.ExecerContext {
+	if st := txStateFromContext(ctx); st != nil {
+		return st.tx
+	}
+	return dbtx
+}
+
+// Queryer returns the transaction stored in the context (see WithTx) or dbtx if there is none
+func Queryer(ctx context.Context, dbtx sqlx.QueryerContext) sqlx.QueryerContext {
+	if st := txStateFromContext(ctx); st != nil {
+		return st.tx
+	}
+	return dbtx
+}
+
+// AfterCommit registers fn to be called after the transaction stored in the context is committed
+// (e.g. to publish events only when the changes are persisted). If fn is registered inside a savepoint
+// that is rolled back, it is discarded. If the context has no transaction, fn is called immediately.
+func AfterCommit(ctx context.Context, fn func()) {
+	st := txStateFromContext(ctx)
+	if st == nil {
+		fn()
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	h := st.top()
+	h.afterCommit = append(h.afterCommit, fn)
+}
+
+// AfterRollback registers fn to be called after the transaction stored in the context (or the current
+// savepoint) is rolled back. If the context has no transaction, fn is never called.
+func AfterRollback(ctx context.Context, fn func()) {
+	st := txStateFromContext(ctx)
+	if st == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	h := st.top()
+	h.afterRollback = append(h.afterRollback, fn)
+}
+
+// CommitContext commits the transaction stored in the context by WithTx and runs its AfterCommit
+// hooks (or its AfterRollback hooks if the commit fails).
+func CommitContext(ctx context.Context) error {
+	st := txStateFromContext(ctx)
+	if st == nil {
+		return errors.New("no transaction in context")
+	}
+	if err := st.tx.Commit(); err != nil {
+		st.done(false)
+		return err
+	}
+	st.done(true)
+	return nil
+}
+
+// RollbackContext rolls back the transaction stored in the context by WithTx and runs its
+// AfterRollback hooks.
+func RollbackContext(ctx context.Context) error {
+	st := txStateFromContext(ctx)
+	if st == nil {
+		return errors.New("no transaction in context")
+	}
+	err := st.tx.Rollback()
+	st.done(false)
+	return err
+}
+
+// WrapContext creates a new DB transaction (with opts) that automatically commits or performs a
+// rollback when fn returns. If fn panics, the transaction is rolled back before re-panicking.
+//
+// The transaction is stored in the context passed to fn. If WrapContext is called with this context
+// (e.g. by a nested repository function), it does not begin a new transaction: fn runs inside a
+// SAVEPOINT of the existing one, which is released when fn succeeds or rolled back (ROLLBACK TO SAVEPOINT)
+// when fn fails. opts is ignored by nested calls.
+//
+// The AfterCommit hooks registered with the context run after the transaction is committed, and the
+// AfterRollback hooks after it is rolled back.
+func WrapContext(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(ctx context.Context, tx *sqlx.Tx) error) (err error) {
+	if st := txStateFromContext(ctx); st != nil {
+		return wrapSavepoint(ctx, st, fn)
+	}
+	tx, err := db.BeginTxx(ctx, opts)
 	if err != nil {
 		return err
 	}
-	if err := fn(tx); err != nil {
-		tx.Rollback()
+	st := newTxState(tx)
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			st.done(false)
+			panic(p)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txKey, st), tx); err != nil {
+		rerr := tx.Rollback()
+		st.done(false)
+		if rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rerr)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		st.done(false)
+		return err
+	}
+	st.done(true)
+	return nil
+}
+
+// wrapSavepoint runs fn inside a savepoint of the transaction st
+func wrapSavepoint(ctx context.Context, st *txState, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	name := st.beginSavepoint()
+	if _, err := st.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		st.endSavepoint(false)
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			st.endSavepoint(false)
+			panic(p)
+		}
+	}()
+	if err := fn(ctx, st.tx); err != nil {
+		_, rerr := st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		st.endSavepoint(false)
+		if rerr != nil {
+			return fmt.Errorf("%w (rollback to savepoint failed: %v)", err, rerr)
+		}
+		return err
+	}
+	if _, err := st.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		st.endSavepoint(false)
 		return err
 	}
-	return tx.Commit()
+	st.endSavepoint(true)
+	return nil
 }
@@ -0,0 +1,149 @@
+package protodb_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapContext(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, protodb.WrapContext(ctx, db, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context, tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE a SET b=1")
+		return err
+	}))
+
+	errFail := errors.New("fail")
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	require.Equal(t, errFail, protodb.WrapContext(ctx, db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		return errFail
+	}))
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	require.PanicsWithValue(t, "boom", func() {
+		_ = protodb.WrapContext(ctx, db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+			panic("boom")
+		})
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWrapContextNested(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	ctx := context.Background()
+	errFail := errors.New("fail")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT protodb_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT protodb_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT protodb_sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT protodb_sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	require.NoError(t, protodb.WrapContext(ctx, db, nil, func(ctx context.Context, outer *sqlx.Tx) error {
+		require.NoError(t, protodb.WrapContext(ctx, db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+			require.Equal(t, outer, tx)
+			return nil
+		}))
+		// the failure of the nested call is handled by the outer transaction
+		require.Equal(t, errFail, protodb.WrapContext(ctx, db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+			return errFail
+		}))
+		return nil
+	}))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	ctx := protodb.WithTx(context.Background(), tx)
+	ctxtx, ok := protodb.TxFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, tx, ctxtx)
+	_, ok = protodb.TxFromContext(context.Background())
+	require.False(t, ok)
+
+	item := &struct {
+		ID   int    `db:"id,table=agents,key"`
+		Name string `db:"name"`
+	}{ID: 1, Name: "a"}
+
+	// the tx in the context is used instead of db (nil)
+	mock.ExpectExec("INSERT INTO agents").WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err = protodb.InsertContext(ctx, nil, item, nil)
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT id, name FROM agents").WillReturnRows(mock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	require.NoError(t, protodb.GetContext(ctx, nil, item, nil))
+
+	committed := false
+	protodb.AfterCommit(ctx, func() { committed = true })
+	mock.ExpectCommit()
+	require.NoError(t, protodb.CommitContext(ctx))
+	require.True(t, committed)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWrapContextHooks(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	events := make([]string, 0)
+
+	// without a transaction, AfterCommit runs immediately
+	protodb.AfterCommit(context.Background(), func() { events = append(events, "now") })
+	protodb.AfterRollback(context.Background(), func() { events = append(events, "never") })
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT protodb_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT protodb_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT protodb_sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT protodb_sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	require.NoError(t, protodb.WrapContext(context.Background(), db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		protodb.AfterCommit(ctx, func() { events = append(events, "outer committed") })
+		_ = protodb.WrapContext(ctx, db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+			protodb.AfterCommit(ctx, func() { events = append(events, "released committed") })
+			return nil
+		})
+		_ = protodb.WrapContext(ctx, db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+			protodb.AfterCommit(ctx, func() { events = append(events, "discarded") })
+			protodb.AfterRollback(ctx, func() { events = append(events, "savepoint rolled back") })
+			return errors.New("fail")
+		})
+		require.Equal(t, []string{"now", "savepoint rolled back"}, events)
+		return nil
+	}))
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	_ = protodb.WrapContext(context.Background(), db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		protodb.AfterCommit(ctx, func() { events = append(events, "discarded") })
+		protodb.AfterRollback(ctx, func() { events = append(events, "rolled back") })
+		return errors.New("fail")
+	})
+
+	require.Equal(t, []string{"now", "savepoint rolled back", "outer committed", "released committed", "rolled back"}, events)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
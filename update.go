@@ -4,15 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"reflect"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+var updateScanTags = []string{"db_update", "dbupdate", "update", "db"}
+
 // UpdateColumnScan uses db_update, dbupdate, update, db (in this order) to map columns and values to be updateed
 func UpdateColumnScan(v interface{}, tags ...string) ColumnsResult {
-	tags = append(tags, "db_update", "dbupdate", "update", "db")
+	if r, ok := protoColumnScan(v, false); ok {
+		return r
+	}
+	tags = append(tags, updateScanTags...)
 	result, err := extract(v, map[string]string{"db": ","}, tags...)
 	return ColumnsResult{
 		Err:     err,
@@ -22,11 +29,35 @@ func UpdateColumnScan(v interface{}, tags ...string) ColumnsResult {
 
 // UpdateContext executes a UpdateColumnScan on dest (with reflection) to determine which table and rows are used
 // to insert data. Use qfn to apply where filters (and other query modifiers).
+// If a column is flagged with the "version" subtag (optimistic locking), it is incremented by the update
+// and the row is only updated if it still has the version of the item. A *StaleObjectError is returned
+// if no row is updated; otherwise the version field of the item is incremented (item must be a pointer).
+// The columns flagged with "autoupdate" are set to the current time (see WithClock) and the ones flagged
+// with "autocreate" are not updated.
+// Example:
+//      type Order struct {
+//         ID      int    `db:"id,table=orders"`
+//         Status  string `db:"status"`
+//         Version int64  `db:"version,version"`
+//      }
+//      // UPDATE orders SET status = ?, version = version + 1 WHERE id = ? AND version = ?
+//      protodb.UpdateContext(ctx, db, order, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+//          return rq.Where("id = ?", order.ID)
+//      })
 func UpdateContext(ctx context.Context, dbtx sqlx.ExecerContext, item interface{}, qfn func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder, skipColumns ...string) (sql.Result, error) {
 	skipColumnMap := make(map[string]struct{})
 	for _, v := range skipColumns {
 		skipColumnMap[v] = struct{}{}
 	}
+	return updateContext(ctx, dbtx, item, qfn, skipColumnMap, func(v TagData) bool {
+		return !skipUpdate(v)
+	})
+}
+
+// updateContext executes the UPDATE of item. The columns of skipColumnMap are never set; the other
+// columns (except the version, autocreate and autoupdate ones) are set if set(column) is true.
+func updateContext(ctx context.Context, dbtx sqlx.ExecerContext, item interface{}, qfn func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder, skipColumnMap map[string]struct{}, set func(v TagData) bool) (sql.Result, error) {
+	dbtx = Execer(ctx, dbtx)
 	// 1 - extract ther underlying type
 	value := reflect.ValueOf(item)
 	if value.Kind() != reflect.Struct && value.IsNil() {
@@ -45,24 +76,114 @@ func UpdateContext(ctx context.Context, dbtx sqlx.ExecerContext, item interface{
 	if tname == "" {
 		return nil, errors.New("(update) subtag 'table' not found")
 	}
-	rq = squirrel.Update(tname)
+	dialect := DialectOf(ctx, dbtx)
+	rq = dialect.Builder().Update(tname)
+	version, hasVersion := columns.versionColumn()
+	var versionValue reflect.Value
+	if hasVersion {
+		vv, err := versionField(version)
+		if err != nil {
+			return nil, err
+		}
+		versionValue = vv
+	}
+	t := now(ctx)
+	autoupdate := make([]reflect.Value, 0)
 	for _, v := range columns.Columns {
-		if v.Name != "-" && v.Name != "" {
+		if hasVersion && v.Name == version.Name {
+			rq = rq.Set(dialect.column(v.Name), squirrel.Expr(dialect.column(v.Name)+" + 1"))
+			continue
+		}
+		if v.Name == "-" || v.Name == "" || isAutoTime(v, "autocreate") {
+			continue
+		}
+		if isAutoTime(v, "autoupdate") && v.FieldValue.IsValid() {
 			if _, ok := skipColumnMap[v.Name]; !ok {
-				if !skipUpdate(v) {
-					rq = rq.Set(v.Name, resolveValue(v))
-				}
+				rq = rq.Set(dialect.column(v.Name), timeValue(v.FieldValue, t))
+				autoupdate = append(autoupdate, v.FieldValue)
 			}
+			continue
+		}
+		if _, ok := skipColumnMap[v.Name]; !ok && set(v) {
+			rq = rq.Set(dialect.column(v.Name), resolveValue(v))
 		}
 	}
 	if qfn != nil {
 		rq = qfn(rq)
 	}
+	if hasVersion {
+		rq = rq.Where(squirrel.Eq{dialect.column(version.Name): versionValue.Interface()})
+	}
 	rawq, args, err := rq.ToSql()
 	if err != nil {
 		return nil, err
 	}
-	return dbtx.ExecContext(ctx, rawq, args...)
+	result, err := execContext(ctx, dbtx, rawq, args...)
+	if err != nil {
+		return nil, err
+	}
+	if !hasVersion {
+		for _, fv := range autoupdate {
+			setTimeValue(fv, t)
+		}
+		return result, nil
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, &StaleObjectError{
+			Name:    tableName(tname),
+			Version: versionValue.Interface(),
+		}
+	}
+	incrementVersion(versionValue)
+	for _, fv := range autoupdate {
+		setTimeValue(fv, t)
+	}
+	return result, nil
+}
+
+// versionColumn returns the column flagged with the "version" subtag
+func (r ColumnsResult) versionColumn() (TagData, bool) {
+	for _, v := range r.Columns {
+		if v.Name != "-" && v.Name != "" && v.MetaFlag("version") {
+			return v, true
+		}
+	}
+	return TagData{}, false
+}
+
+// versionField returns the value of the version column, dereferencing pointers. The version must be
+// an integer (or a non-nil pointer to an integer).
+func versionField(v TagData) (reflect.Value, error) {
+	fv := v.FieldValue
+	for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+		fv = fv.Elem()
+	}
+	if isNilSafe(fv) {
+		return reflect.Value{}, fmt.Errorf("(update) version column %s is nil", v.Name)
+	}
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fv, nil
+	}
+	return reflect.Value{}, fmt.Errorf("(update) version column %s must be an integer (not %v)", v.Name, fv.Type())
+}
+
+// incrementVersion increments the version field (if settable, see versionField)
+func incrementVersion(v reflect.Value) {
+	if !v.CanSet() {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(v.Uint() + 1)
+	}
 }
 
 type Skippable interface {
@@ -95,6 +216,11 @@ func skipUpdate(v TagData) bool {
 }
 
 func resolveValue(v TagData) interface{} {
+	if v.model != nil {
+		if x, ok := v.model.ProtodbValue(v.Name); ok {
+			return x
+		}
+	}
 	if isNilSafe(v.FieldValue) {
 		if vs, ok := v.MetaStringCheck("nilval"); ok {
 			return vs
@@ -106,5 +232,8 @@ func resolveValue(v TagData) interface{} {
 			return nil
 		}
 	}
+	if ts, ok := v.FieldValue.Interface().(*timestamppb.Timestamp); ok {
+		return ts.AsTime()
+	}
 	return v.FieldValue.Interface()
 }
@@ -2,6 +2,7 @@ package protodb_test
 
 import (
 	"context"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -51,3 +52,90 @@ func TestUpdateContext(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, int64(1), ra)
 }
+
+func TestUpdateContextBareFlags(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	// skipnil, skipzero and zeronil need a value ("skipnil=true"): without one they are ignored
+	item := &struct {
+		ID    int     `db:"id,table=agents,key"`
+		Name  string  `db:"name,skipzero"`
+		Notes string  `db:"notes,zeronil"`
+		Score *int    `db:"score,skipnil"`
+		Email *string `db:"email,skipnil=true"`
+	}{ID: 1}
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE agents SET name = ?, notes = ?, score = ? WHERE id = ?")).
+		WithArgs("", "", nil, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.UpdateContext(context.Background(), db, item, func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", item.ID)
+	}, "id")
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateContextVersion(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	item := &struct {
+		ID      int    `db:"id,table=orders"`
+		Status  string `db:"status"`
+		Version int64  `db:"version,version"`
+	}{
+		ID:      1,
+		Status:  "paid",
+		Version: 3,
+	}
+	qfn := func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", item.ID)
+	}
+
+	mock.ExpectExec("UPDATE orders SET id = \\?, status = \\?, version = version \\+ 1 WHERE id = \\? AND version = \\?").
+		WithArgs(1, "paid", 1, int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.UpdateContext(context.Background(), db, item, qfn)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), item.Version)
+
+	mock.ExpectExec("UPDATE orders SET id = \\?, status = \\?, version = version \\+ 1 WHERE id = \\? AND version = \\?").
+		WithArgs(1, "paid", 1, int64(4)).WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = protodb.UpdateContext(context.Background(), db, item, qfn)
+	require.Error(t, err)
+	require.True(t, protodb.IsStaleObject(err))
+	require.ErrorIs(t, err, protodb.ErrStaleObject)
+	require.Equal(t, int64(4), item.Version)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateContextVersionPointer(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+	version := int32(3)
+	item := &struct {
+		ID      int    `db:"id,table=orders,key"`
+		Version *int32 `db:"version,version"`
+	}{ID: 1, Version: &version}
+	qfn := func(rq squirrel.UpdateBuilder) squirrel.UpdateBuilder {
+		return rq.Where("id = ?", item.ID)
+	}
+
+	// the pointer is dereferenced
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE orders SET id = ?, version = version + 1 WHERE id = ? AND version = ?")).
+		WithArgs(1, 1, int32(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := protodb.UpdateContext(context.Background(), db, item, qfn)
+	require.NoError(t, err)
+	require.Equal(t, int32(4), *item.Version)
+
+	// nil and non-integer versions are rejected before the update
+	item.Version = nil
+	_, err = protodb.UpdateContext(context.Background(), db, item, qfn)
+	require.EqualError(t, err, "(update) version column version is nil")
+	_, err = protodb.UpdateContext(context.Background(), db, &struct {
+		ID      int    `db:"id,table=orders,key"`
+		Version string `db:"version,version"`
+	}{ID: 1, Version: "3"}, nil)
+	require.EqualError(t, err, "(update) version column version must be an integer (not string)")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,160 @@
+package protodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+// UpsertContext inserts items (a struct pointer or a slice) like InsertContext, updating the existing rows
+// when a unique key conflicts. The statement is "INSERT ... ON DUPLICATE KEY UPDATE" on MySQL and
+// "INSERT ... ON CONFLICT (keys) DO UPDATE" on PostgreSQL (detected by the driver name of dbtx).
+//
+// Subtags:
+//   - "conflict": the column is part of the conflict target (PostgreSQL). If no column has this subtag,
+//                 the columns tagged with "key" (or "pk") are used.
+//   - "onconflict": what happens to the column when the row already exists:
+//                   "update" (default) overwrites the column with the inserted value,
+//                   "keep" (default of the "autocreate" columns) does not change the column,
+//                   any other value is used as the SQL expression of the new value
+//                   (use a tag separated by ";" like dbinsert if the expression has commas).
+//                   The columns of the expression must be qualified with the table name (counters.counter)
+//                   or EXCLUDED, since PostgreSQL rejects the unqualified references (they are ambiguous
+//                   with EXCLUDED); an error is returned if it references a column of the item unqualified.
+// If no column is updated, the PostgreSQL statement is "ON CONFLICT (keys) DO NOTHING": the skipped rows
+// are not returned, so the RETURNING columns ("autoinc", "returning") are matched with the items by the
+// conflict columns, and an error is returned if they are not inserted (e.g. an "autoinc" key).
+// Example:
+//      type Example struct {
+//         ID      int    `db:"id,table=counters,key"`
+//         Name    string `db:"name,onconflict=keep"`
+//         Counter int    `dbinsert:"counter;onconflict=counters.counter+1"`
+//      }
+func UpsertContext(ctx context.Context, dbtx sqlx.ExecerContext, items interface{}, qfn func(rq squirrel.InsertBuilder) squirrel.InsertBuilder) (sql.Result, error) {
+	dialect := DialectOf(ctx, dbtx)
+	return insertContext(ctx, dbtx, items, qfn, func(plan *insertPlan, rq squirrel.InsertBuilder) (squirrel.InsertBuilder, error) {
+		clause, err := onConflictClause(plan, dialect)
+		if err != nil {
+			return rq, err
+		}
+		return rq.Suffix(clause), nil
+	})
+}
+
+// conflictColumns returns the columns of the conflict target
+func conflictColumns(columns []TagData) []string {
+	keys := make([]string, 0)
+	for _, v := range columns {
+		if v.Name != "-" && v.Name != "" && v.MetaFlag("conflict") {
+			keys = append(keys, v.Name)
+		}
+	}
+	if len(keys) > 0 {
+		return keys
+	}
+	for _, v := range columns {
+		if v.Name != "-" && v.Name != "" && v.IsKey() {
+			keys = append(keys, v.Name)
+		}
+	}
+	return keys
+}
+
+// sqlTokenRe matches the string literals, numbers and identifiers (with the "." or "::" that qualifies
+// them) of a SQL expression
+var sqlTokenRe = regexp.MustCompile(`'(?:[^']|'')*'|\d[\w.]*|(\.\s*|::\s*)?("(?:[^"]|"")*"|[A-Za-z_][\w$]*)`)
+
+// unqualifiedColumn returns the first column of columns referenced by the SQL expression without a
+// table (or EXCLUDED) qualifier. Function names, qualifiers and type casts are ignored.
+func unqualifiedColumn(expr string, columns []TagData) (string, bool) {
+	for _, m := range sqlTokenRe.FindAllStringSubmatchIndex(expr, -1) {
+		if m[4] < 0 || m[2] >= 0 {
+			// literal or qualified identifier
+			continue
+		}
+		if next := strings.TrimLeft(expr[m[5]:], " \t\r\n"); strings.HasPrefix(next, ".") || strings.HasPrefix(next, "(") {
+			// qualifier or function
+			continue
+		}
+		ident := expr[m[4]:m[5]]
+		for _, v := range columns {
+			if v.Name == "-" || v.Name == "" {
+				continue
+			}
+			if strings.HasPrefix(ident, `"`) {
+				if strings.ReplaceAll(ident[1:len(ident)-1], `""`, `"`) == v.Name {
+					return v.Name, true
+				}
+			} else if strings.EqualFold(ident, v.Name) {
+				return v.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// onConflictClause builds the ON DUPLICATE KEY UPDATE (or ON CONFLICT) suffix of an upsert
+func onConflictClause(plan *insertPlan, dialect Dialect) (string, error) {
+	keys := conflictColumns(plan.scan.Columns)
+	keyset := make(map[string]struct{})
+	for i, k := range keys {
+		keyset[k] = struct{}{}
+		keys[i] = dialect.column(k)
+	}
+	sets := make([]string, 0, len(plan.columns))
+	for _, v := range plan.columns {
+		if _, ok := keyset[v.Name]; ok {
+			continue
+		}
+		col := dialect.column(v.Name)
+		defaultAction := "update"
+		if isAutoTime(v, "autocreate") {
+			defaultAction = "keep"
+		}
+		switch action := v.MetaString("onconflict", defaultAction); action {
+		case "keep":
+			continue
+		case "update", "":
+			if dialect == PostgreSQL {
+				sets = append(sets, col+" = EXCLUDED."+col)
+			} else {
+				sets = append(sets, col+" = VALUES("+col+")")
+			}
+		default:
+			if name, ok := unqualifiedColumn(action, plan.scan.Columns); ok && dialect == PostgreSQL {
+				return "", fmt.Errorf("(upsert) onconflict expression of %s must qualify the column %s with the table name (e.g. %s.%s)",
+					v.Name, name, tableName(plan.table), name)
+			}
+			sets = append(sets, col+" = "+action)
+		}
+	}
+	if dialect == PostgreSQL {
+		if len(keys) < 1 {
+			return "", errors.New("(upsert) no conflict columns found (use the 'conflict' or 'key' subtag)")
+		}
+		if len(sets) < 1 {
+			// the skipped rows are not returned (see insertReturning)
+			plan.doNothing = true
+			return "ON CONFLICT (" + strings.Join(keys, ", ") + ") DO NOTHING", nil
+		}
+		return "ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + strings.Join(sets, ", "), nil
+	}
+	if len(sets) < 1 {
+		if len(plan.columns) < 1 {
+			return "", errors.New("(upsert) no columns to insert")
+		}
+		// no-op update (keeps the existing row)
+		noop := dialect.column(plan.columns[0].Name)
+		if len(keys) > 0 {
+			noop = keys[0]
+		}
+		return "ON DUPLICATE KEY UPDATE " + noop + " = " + noop, nil
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), nil
+}
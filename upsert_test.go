@@ -0,0 +1,115 @@
+package protodb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pedidopago/protodb"
+	ptesting "github.com/pedidopago/protodb/testing"
+	"github.com/stretchr/testify/require"
+)
+
+type upsertCounter struct {
+	ID      int    `db:"id,table=counters,key"`
+	Name    string `db:"name,onconflict=keep"`
+	Label   string `db:"label"`
+	Counter int    `db:"-" dbinsert:"counter;onconflict=counters.counter+1"`
+}
+
+func TestUpsertContextMySQL(t *testing.T) {
+	db, mock := ptesting.MockDBMySQL(t)
+	defer db.Close()
+
+	item := &upsertCounter{ID: 1, Name: "a", Label: "A", Counter: 1}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO counters (id,name,label,counter) VALUES (?,?,?,?) ON DUPLICATE KEY UPDATE label = VALUES(label), counter = counters.counter+1")).
+		WithArgs(1, "a", "A", 1).WillReturnResult(sqlmock.NewResult(1, 2))
+	_, err := protodb.UpsertContext(context.Background(), db, item, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertContextPostgres(t *testing.T) {
+	db, mock := ptesting.MockDBPostgres(t)
+	defer db.Close()
+
+	items := []upsertCounter{
+		{ID: 1, Name: "a", Label: "A", Counter: 1},
+		{ID: 2, Name: "b", Label: "B", Counter: 1},
+	}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO counters (id,name,label,counter) VALUES ($1,$2,$3,$4),($5,$6,$7,$8) ON CONFLICT (id) DO UPDATE SET label = EXCLUDED.label, counter = counters.counter+1")).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	_, err := protodb.UpsertContext(context.Background(), db, &items, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// the columns of the expressions must be qualified on postgres
+	unqualified := &struct {
+		ID      int `db:"id,table=counters,key"`
+		Counter int `db:"-" dbinsert:"counter;onconflict=counter+1"`
+	}{ID: 1, Counter: 1}
+	_, err = protodb.UpsertContext(context.Background(), db, unqualified, nil)
+	require.EqualError(t, err, "(upsert) onconflict expression of counter must qualify the column counter with the table name (e.g. counters.counter)")
+
+	// every column of the expression is checked
+	unqualifiedOther := &struct {
+		ID    int `db:"id,table=orders,key"`
+		Qty   int `db:"qty"`
+		Total int `db:"-" dbinsert:"total;onconflict=orders.total+qty"`
+	}{ID: 1, Qty: 2, Total: 10}
+	_, err = protodb.UpsertContext(context.Background(), db, unqualifiedOther, nil)
+	require.EqualError(t, err, "(upsert) onconflict expression of total must qualify the column qty with the table name (e.g. orders.qty)")
+
+	// functions, literals, casts and qualified columns are not checked
+	qualified := &struct {
+		ID    int    `db:"id,table=orders,key"`
+		Qty   int    `db:"qty"`
+		Total int    `db:"-" dbinsert:"total;onconflict=GREATEST(orders.total, EXCLUDED.qty)::int"`
+		Note  string `db:"-" dbinsert:"note;onconflict=COALESCE(\"orders\".note, 'qty', 'total')"`
+	}{ID: 1, Qty: 2, Total: 10, Note: "n"}
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO orders (id,qty,total,note) VALUES ($1,$2,$3,$4) ON CONFLICT (id) DO UPDATE SET qty = EXCLUDED.qty, total = GREATEST(orders.total, EXCLUDED.qty)::int, note = COALESCE("orders".note, 'qty', 'total')`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = protodb.UpsertContext(context.Background(), db, qualified, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// the conflict target is required on postgres
+	noKey := &struct {
+		ID   int    `db:"id,table=counters"`
+		Name string `db:"name"`
+	}{ID: 1, Name: "a"}
+	_, err = protodb.UpsertContext(context.Background(), db, noKey, nil)
+	require.Error(t, err)
+}
+
+func TestUpsertContextDoNothingReturning(t *testing.T) {
+	db, mock := ptesting.MockDBPostgres(t)
+	defer db.Close()
+
+	type tag struct {
+		Name      string `db:"name,table=tags,key"`
+		CreatedAt string `db:"created_at,skipzero=true,returning,onconflict=keep"`
+	}
+	// the skipped rows are not returned: the returned rows are matched by the conflict columns
+	items := []tag{{Name: "a"}, {Name: "b"}}
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO tags (name) VALUES ($1),($2) ON CONFLICT (name) DO NOTHING RETURNING created_at, name")).
+		WithArgs("a", "b").
+		WillReturnRows(mock.NewRows([]string{"created_at", "name"}).AddRow("2021-01-02", "b"))
+	result, err := protodb.UpsertContext(context.Background(), db, &items, nil)
+	require.NoError(t, err)
+	require.Equal(t, "", items[0].CreatedAt)
+	require.Equal(t, "2021-01-02", items[1].CreatedAt)
+	ra, err := result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), ra)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// the rows can't be matched if the conflict column is generated by the database
+	generated := &struct {
+		ID   int64  `db:"id,table=tags,key,autoinc"`
+		Name string `db:"name,onconflict=keep"`
+	}{Name: "a"}
+	_, err = protodb.UpsertContext(context.Background(), db, generated, nil)
+	require.Error(t, err)
+}
@@ -2,11 +2,15 @@ package protodb
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/lann/builder"
 )
 
 // source: github.com/jmoiron/sqlx
@@ -27,9 +31,26 @@ func isTypeSliceOrSlicePointer(t reflect.Type) bool {
 type contextVar string
 
 const (
-	joinReplace contextVar = "join_replace"
+	joinReplace           contextVar = "join_replace"
+	allowUnfilteredDelete contextVar = "allow_unfiltered_delete"
+	insertBatchSize       contextVar = "insert_batch_size"
+	dialectKey            contextVar = "dialect"
+	transformFuncs        contextVar = "transform_funcs"
+	txKey                 contextVar = "tx"
+	includeDeleted        contextVar = "include_deleted"
+	hardDelete            contextVar = "hard_delete"
+	clockKey              contextVar = "clock"
+	readMaskKey           contextVar = "read_mask"
 )
 
+// contextFlag returns true if the context value of key is a true bool
+func contextFlag(ctx context.Context, key contextVar) bool {
+	if v, ok := ctx.Value(key).(bool); ok {
+		return v
+	}
+	return false
+}
+
 func extractJoinReplace(ctx context.Context) map[string]string {
 	v := ctx.Value(joinReplace)
 	if v == nil {
@@ -63,3 +84,70 @@ func isNilSafe(v reflect.Value) bool {
 	}
 	return false
 }
+
+// driverName returns the driver name of a *sqlx.DB or *sqlx.Tx (or "" if unknown)
+func driverName(dbtx interface{}) string {
+	if dn, ok := dbtx.(interface{ DriverName() string }); ok {
+		return dn.DriverName()
+	}
+	return ""
+}
+
+// placeholderLimit returns the max number of bind parameters of a single statement
+func placeholderLimit(dbtx interface{}) int {
+	switch driverName(dbtx) {
+	case "sqlite3", "sqlite":
+		return 999
+	}
+	// mysql, postgres, pgx
+	return 65535
+}
+
+// setIntValue sets an integer (or a string or a pointer to them) field with id
+func setIntValue(v reflect.Value, id int64) error {
+	if !v.CanSet() {
+		return fmt.Errorf("cannot set %s", v.Type())
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setIntValue(v.Elem(), id)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(id))
+	case reflect.String:
+		v.SetString(strconv.FormatInt(id, 10))
+	default:
+		return fmt.Errorf("cannot set an id to %s", v.Type())
+	}
+	return nil
+}
+
+// builderHasParts returns true if the (squirrel) builder slice field name is not empty
+func builderHasParts(b interface{}, name string) bool {
+	parts, ok := builder.Get(b, name)
+	if !ok || parts == nil {
+		return false
+	}
+	return reflect.ValueOf(parts).Len() > 0
+}
+
+// execContext executes a statement and classifies the driver error (see ClassifyError)
+func execContext(ctx context.Context, dbtx sqlx.ExecerContext, query string, args ...interface{}) (sql.Result, error) {
+	result, err := dbtx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, ClassifyError(err)
+	}
+	return result, nil
+}
+
+// tableName returns the table name of a "table" subtag (without the alias)
+func tableName(table string) string {
+	if fields := strings.Fields(table); len(fields) > 0 {
+		return fields[0]
+	}
+	return table
+}